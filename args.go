@@ -86,6 +86,7 @@ func groupCondition(andWh *[]Condition, v []any) string {
 			TableName: v[3].(string),
 			Relation:  v[4].(string),
 			FieldType: ftype,
+			expr:      v[0],
 		})
 		relation = v[4].(string)
 	case 4:
@@ -95,6 +96,7 @@ func groupCondition(andWh *[]Condition, v []any) string {
 			Value:     v[2],
 			TableName: v[3].(string),
 			FieldType: ftype,
+			expr:      v[0],
 		})
 	case 3:
 		*andWh = append(*andWh, Condition{
@@ -102,6 +104,7 @@ func groupCondition(andWh *[]Condition, v []any) string {
 			Condition: v[1].(string),
 			Value:     v[2],
 			FieldType: ftype,
+			expr:      v[0],
 		})
 	case 2:
 		*andWh = append(*andWh, Condition{
@@ -109,6 +112,7 @@ func groupCondition(andWh *[]Condition, v []any) string {
 			Condition: "=",
 			Value:     v[1],
 			FieldType: ftype,
+			expr:      v[0],
 		})
 	}
 	return relation
@@ -131,6 +135,7 @@ func (r *twoArgs) ParseArgs(relation string, args ...any) []GroupWhere {
 				Condition: "=",
 				Value:     args[1],
 				FieldType: ftype,
+				expr:      args[0],
 			},
 		},
 	})
@@ -154,6 +159,7 @@ func (r *threeArgs) ParseArgs(relation string, args ...any) []GroupWhere {
 				Condition: args[1].(string),
 				Value:     args[2],
 				FieldType: ftype,
+				expr:      args[0],
 			},
 		},
 	})
@@ -178,6 +184,7 @@ func (r *fourArgs) ParseArgs(relation string, args ...any) []GroupWhere {
 				Value:     args[2],
 				TableName: args[3].(string),
 				FieldType: ftype,
+				expr:      args[0],
 			},
 		},
 	})
@@ -206,19 +213,127 @@ func (r *fiveArgs) ParseArgs(relation string, args ...any) []GroupWhere {
 				TableName: args[3].(string),
 				Relation:  args[4].(string),
 				FieldType: ftype,
+				expr:      args[0],
 			},
 		},
 	})
 	return groupWhere
 }
 
-func parseAggregation(args ...any) (string, int64) {
-	for _, v := range args {
-		if val, ok := v.(string); ok {
-			if hasIllegalStr(val) {
-				return "", 0
+// checkConditionArgs 校验 WHERE/HAVING 参数，clause 用于错误信息
+func checkConditionArgs(clause string, args ...any) error {
+	if len(args) > 5 {
+		// 自定义参数处理器，交由其自行解析
+		return nil
+	}
+	if len(args) == 1 {
+		switch args[0].(type) {
+		case [][]any, [][][]any:
+		default:
+			if _, ok := argsMap[1].(*oneArgs); ok {
+				return fmt.Errorf("不支持的 %s 条件类型: %T", clause, args[0])
+			}
+			return nil
+		}
+	}
+	var err error
+	eachCondition(args, func(v []any) {
+		if err == nil {
+			err = checkCondition(clause, v)
+		}
+	})
+	return err
+}
+
+// eachCondition 遍历平铺或分组形式的每个条件
+func eachCondition(args []any, fn func(v []any)) {
+	if len(args) != 1 {
+		fn(args)
+		return
+	}
+	switch whs := args[0].(type) {
+	case [][]any:
+		for _, v := range whs {
+			fn(v)
+		}
+	case [][][]any:
+		for _, group := range whs {
+			for _, v := range group {
+				fn(v)
+			}
+		}
+	}
+}
+
+// skippedConditions 返回条件中无法渲染的部分（被拒绝的载体、空字段条件、未知的运算符），
+// 调用方将其作为错误返回，不静默忽略
+func skippedConditions(clause string, args ...any) []error {
+	var errs []error
+	eachCondition(args, func(v []any) {
+		if len(v) < 2 {
+			return
+		}
+		if f, ok := v[0].(string); ok && f == "" && len(args) == 1 {
+			if _, isSub := v[len(v)-1].(*sqlBuilder); !isSub {
+				errs = append(errs, fmt.Errorf("%s 分组条件字段为空: %v", clause, v))
+			}
+		}
+		value := v[1]
+		if len(v) >= 3 {
+			value = v[2]
+			if op, ok := v[1].(string); ok && !knownOperator(op) {
+				errs = append(errs, fmt.Errorf("%s 条件无效: 未知的运算符 %s", clause, op))
+			}
+		}
+		for _, c := range []any{v[0], value} {
+			if err := carrierErr(c); err != nil {
+				errs = append(errs, fmt.Errorf("%s 条件无效: %w", clause, err))
 			}
 		}
+	})
+	return errs
+}
+
+// checkCondition 校验单个条件中的标识符（字段名、表别名）、运算符和关系
+// 条件值会以占位符绑定，不做字符校验
+func checkCondition(clause string, v []any) error {
+	if len(v) < 2 || len(v) > 5 {
+		return fmt.Errorf("不支持的 %s 参数数量: %d", clause, len(v))
+	}
+	switch f := v[0].(type) {
+	case string:
+		if f != "" && !isSafeIdentifier(f) {
+			return fmt.Errorf("非法的 %s 字段名: %s", clause, f)
+		}
+	case *funCarrier, *literalCarrier, *jsonFieldCarrier, *sqlBuilder:
+	default:
+		return fmt.Errorf("不支持的 %s 字段类型: %T", clause, v[0])
+	}
+	if len(v) >= 3 {
+		// 已注册的运算符（含 != 及 != any 等）直接放行；未注册的运算符带危险字符时报错为非法，
+		// 否则由 skippedConditions 报告为未知的运算符
+		op, ok := v[1].(string)
+		if !ok || !knownOperator(op) && hasIllegalStr(op) {
+			return fmt.Errorf("非法的 %s 运算符: %v", clause, v[1])
+		}
+	}
+	if len(v) >= 4 {
+		if tbl, ok := v[3].(string); !ok || !isSafeIdentifier(tbl) {
+			return fmt.Errorf("非法的 %s 表别名: %v", clause, v[3])
+		}
+	}
+	if len(v) == 5 {
+		rel, ok := v[4].(string)
+		if lc := strings.ToLower(rel); !ok || (lc != "and" && lc != "or") {
+			return fmt.Errorf("非法的 %s 条件关系: %v", clause, v[4])
+		}
+	}
+	return nil
+}
+
+func parseAggregation(args ...any) (string, int64) {
+	if checkCondition("", args) != nil {
+		return "", 0
 	}
 	var nfield string
 	var ftype int64
@@ -256,13 +371,7 @@ func parseAggregation(args ...any) (string, int64) {
 		ftype = 2
 	case *jsonFieldCarrier:
 		val := args[0].(*jsonFieldCarrier)
-		if val.Field != "" {
-			if val.TableAlias != "" {
-				nfield = fmt.Sprintf("`%s`.`%s`%s'%s'", val.TableAlias, val.Field, val.Arrow, val.Path)
-			} else {
-				nfield = fmt.Sprintf("`%s`%s'%s'", val.Field, val.Arrow, val.Path)
-			}
-		}
+		nfield = val.render(defaultDialect)
 		ftype = 2
 	case *sqlBuilder:
 		nfield = ""
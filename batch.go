@@ -0,0 +1,188 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Statement 一条待执行的 SQL 语句及其参数
+type Statement struct {
+	SQL  string
+	Args []any
+}
+
+// BatchLimit 批量插入的拆分限制，字段为 0 表示不限制
+type BatchLimit struct {
+	// 每条语句最多的行数
+	MaxRows int
+	// 每条语句最多的占位符数量（MySQL/PostgreSQL 为 65535，SQL Server 为 2100）
+	MaxPlaceholders int
+	// 每条语句 SQL 与参数的最大字节数（估算），用于避免超过 max_allowed_packet
+	MaxBytes int
+}
+
+// BuildSliceMapInsertBatch 使用 map 切片构建批量插入 SQL，按 limit 拆分为多条语句
+// 所有语句使用相同的列，列的确定方式与 BuildSliceMapInsert 一致
+func (b *sqlBuilder) BuildSliceMapInsertBatch(option []map[string]any, limit BatchLimit) (stmts []Statement, err error) {
+	defer b.observeBatch("BuildSliceMapInsertBatch")(&stmts, &err)
+	if err := b.checkErr(); err != nil {
+		return nil, err
+	}
+	if len(option) == 0 {
+		return nil, nil
+	}
+	keys, err := b.rowKeys(option)
+	if err != nil {
+		return nil, err
+	}
+	rowArgs := func(i int) []any {
+		args := make([]any, len(keys))
+		for j, k := range keys {
+			args[j] = b.rowValue(option[i], k)
+		}
+		return args
+	}
+	return b.splitBatch(len(option), rowArgs, limit, func(from, to int) (string, []any, error) {
+		return b.renderSliceMapInsert("insert", keys, option[from:to])
+	})
+}
+
+// BuildSliceStructInsertBatch 使用结构体切片构建批量插入 SQL，按 limit 拆分为多条语句
+// 所有语句使用相同的列，列的确定方式与 BuildSliceStructInsert 一致
+func (b *sqlBuilder) BuildSliceStructInsertBatch(entity any, limit BatchLimit) (stmts []Statement, err error) {
+	defer b.observeBatch("BuildSliceStructInsertBatch")(&stmts, &err)
+	if err := b.checkErr(); err != nil {
+		return nil, err
+	}
+	if b.dbTag == "" {
+		b.dbTag = "db"
+	}
+	reflectVal := reflect.ValueOf(entity)
+	if reflectVal.Kind() != reflect.Ptr || reflectVal.IsNil() {
+		return nil, errors.New("参数不是指针类型")
+	}
+	elemVal := reflectVal.Elem()
+	if elemVal.Kind() != reflect.Slice {
+		return nil, errors.New("参数不是指针切片类型")
+	}
+	if elemVal.Len() == 0 {
+		return nil, nil
+	}
+	keys, rows, err := b.structSliceRows(elemVal)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("没有可插入的字段")
+	}
+	rowArgs := func(i int) []any {
+		args := make([]any, len(keys))
+		for j, k := range keys {
+			args[j] = b.rowValue(rows[i], k)
+		}
+		return args
+	}
+	return b.splitBatch(len(rows), rowArgs, limit, func(from, to int) (string, []any, error) {
+		return b.renderSliceMapInsert("insert", keys, rows[from:to])
+	})
+}
+
+// splitBatch 按 limit 将 n 行切分为若干段，并用 build 构建每一段
+// rowArgs 返回第 i 行的参数，用于估算占位符数量和字节数
+func (b *sqlBuilder) splitBatch(n int, rowArgs func(i int) []any, limit BatchLimit, build func(from, to int) (string, []any, error)) ([]Statement, error) {
+	d := b.getDialect()
+	_, suffixArgs, err := b.insertSuffix(nil)
+	if err != nil {
+		return nil, err
+	}
+	fixed := len(suffixArgs)
+	if limit.MaxPlaceholders > 0 && fixed >= limit.MaxPlaceholders {
+		return nil, fmt.Errorf("ON DUPLICATE KEY UPDATE / ON CONFLICT 的参数数量 %d 已超出占位符限制 %d", fixed, limit.MaxPlaceholders)
+	}
+
+	// 语句中除行以外部分（表名、列名、ON DUPLICATE KEY 等）的字节数
+	header := 0
+	if limit.MaxBytes > 0 {
+		sql, args, err := build(0, 1)
+		if err != nil {
+			return nil, err
+		}
+		header = statementBytes(sql, args) - rowBytes(d, 0, rowArgs(0))
+	}
+
+	var stmts []Statement
+	for from := 0; from < n; {
+		to := from
+		placeholders := fixed
+		size := header
+		for to < n {
+			if limit.MaxRows > 0 && to-from >= limit.MaxRows {
+				break
+			}
+			args := rowArgs(to)
+			if limit.MaxPlaceholders > 0 && placeholders+len(args) > limit.MaxPlaceholders {
+				break
+			}
+			rb := rowBytes(d, placeholders-fixed, args)
+			if limit.MaxBytes > 0 && to > from && size+rb > limit.MaxBytes {
+				break
+			}
+			placeholders += len(args)
+			size += rb
+			to++
+		}
+		if to == from {
+			return nil, fmt.Errorf("第 %d 行的参数数量超出占位符限制 %d", from+1, limit.MaxPlaceholders)
+		}
+		// 字节数为估算值，构建后再校验，超出时逐行回退
+		for {
+			sql, args, err := build(from, to)
+			if err != nil {
+				return nil, err
+			}
+			if limit.MaxBytes <= 0 || statementBytes(sql, args) <= limit.MaxBytes {
+				stmts = append(stmts, Statement{SQL: sql, Args: args})
+				break
+			}
+			if to-from == 1 {
+				return nil, fmt.Errorf("第 %d 行的语句大小超出字节限制 %d", from+1, limit.MaxBytes)
+			}
+			to--
+		}
+		from = to
+	}
+	return stmts, nil
+}
+
+// rowBytes 估算一行 VALUES 元组及其参数的字节数，offset 为该行之前已使用的占位符数量
+func rowBytes(d Dialect, offset int, args []any) int {
+	// 括号和行之间的逗号
+	size := 3
+	for i, arg := range args {
+		size += len(d.Placeholder(offset+i+1)) + 1 + argBytes(arg)
+	}
+	return size
+}
+
+// statementBytes 估算语句及其参数的字节数
+func statementBytes(sql string, args []any) int {
+	size := len(sql)
+	for _, arg := range args {
+		size += argBytes(arg)
+	}
+	return size
+}
+
+// argBytes 估算单个参数的字节数
+func argBytes(arg any) int {
+	switch val := arg.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(val)
+	case []byte:
+		return len(val)
+	}
+	return 8
+}
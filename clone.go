@@ -0,0 +1,214 @@
+package sqlbuilder
+
+// Clone 深拷贝 builder，返回的副本与原 builder 互不影响
+// 适用于在同一基础查询上分出列表、计数、导出等多个分支
+func (b *sqlBuilder) Clone() *sqlBuilder {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	c.fields = cloneSlice(b.fields)
+	c.fieldValue = cloneSlice(b.fieldValue)
+	c.whr = b.whr.clone()
+	c.hhr = b.hhr.clone()
+	c.scopeWhr = b.scopeWhr.clone()
+	if b.orderField != nil {
+		c.orderField = make([][]any, len(b.orderField))
+		for i, row := range b.orderField {
+			c.orderField[i] = cloneSlice(row)
+		}
+	}
+	c.groupBy = append([]string(nil), b.groupBy...)
+	if b.joins != nil {
+		c.joins = make([]joinClause, len(b.joins))
+		for i, j := range b.joins {
+			j.using = append([]string(nil), j.using...)
+			j.onConds = append([]onCondition(nil), j.onConds...)
+			j.subquery = j.subquery.Clone()
+			c.joins[i] = j
+		}
+	}
+	c.fromQuery = b.fromQuery.Clone()
+	c.emptyFieldMap = cloneMap(b.emptyFieldMap)
+	c.zeroFieldMap = cloneMap(b.zeroFieldMap)
+	c.withoutScopes = cloneMap(b.withoutScopes)
+	c.sqlHints = append([]string(nil), b.sqlHints...)
+	c.indexHints = append([]string(nil), b.indexHints...)
+	if b.unions != nil {
+		c.unions = make([]unionClause, len(b.unions))
+		for i, u := range b.unions {
+			c.unions[i] = unionClause{typ: u.typ, builder: u.builder.Clone()}
+		}
+	}
+	if b.ctes != nil {
+		c.ctes = make([]cteDef, len(b.ctes))
+		for i, cte := range b.ctes {
+			c.ctes[i] = cteDef{
+				name:       cte.name,
+				columns:    append([]string(nil), cte.columns...),
+				definition: cte.definition.Clone(),
+			}
+		}
+	}
+	if b.onDuplicateUpdates != nil {
+		c.onDuplicateUpdates = make(map[string]any, len(b.onDuplicateUpdates))
+		for k, v := range b.onDuplicateUpdates {
+			c.onDuplicateUpdates[k] = cloneValue(v)
+		}
+	}
+	c.onDuplicateExcept = append([]string(nil), b.onDuplicateExcept...)
+	if b.conflict != nil {
+		conflict := *b.conflict
+		conflict.columns = append([]string(nil), b.conflict.columns...)
+		if b.conflict.updates != nil {
+			conflict.updates = make(map[string]any, len(b.conflict.updates))
+			for k, v := range b.conflict.updates {
+				conflict.updates[k] = cloneValue(v)
+			}
+		}
+		conflict.where = b.conflict.where.clone()
+		c.conflict = &conflict
+	}
+	if b.customParts != nil {
+		c.customParts = make([]customPart, len(b.customParts))
+		for i, cp := range b.customParts {
+			c.customParts[i] = customPart{sql: cp.sql, args: cloneSlice(cp.args)}
+		}
+	}
+	c.hooks = append([]Hook(nil), b.hooks...)
+	c.returning = append([]string(nil), b.returning...)
+	c.columnOrder = append([]string(nil), b.columnOrder...)
+	if b.rowDefaults != nil {
+		c.rowDefaults = make(map[string]any, len(b.rowDefaults))
+		for k, v := range b.rowDefaults {
+			c.rowDefaults[k] = cloneValue(v)
+		}
+	}
+	c.skipped = append([]error(nil), b.skipped...)
+	return &c
+}
+
+// clone 深拷贝条件树
+func (r *Where) clone() *Where {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.groupWhere = cloneGroups(r.groupWhere)
+	if r.assembleWhere != nil {
+		c.assembleWhere = make([][]GroupWhere, len(r.assembleWhere))
+		for i, g := range r.assembleWhere {
+			c.assembleWhere[i] = cloneGroups(g)
+		}
+	}
+	return &c
+}
+
+func cloneGroups(groups []GroupWhere) []GroupWhere {
+	if groups == nil {
+		return nil
+	}
+	res := make([]GroupWhere, len(groups))
+	for i, g := range groups {
+		conds := make([]Condition, len(g.Condition))
+		for k, cond := range g.Condition {
+			cond.Value = cloneValue(cond.Value)
+			cond.expr = cloneValue(cond.expr)
+			conds[k] = cond
+		}
+		res[i] = GroupWhere{Relation: g.Relation, Condition: conds}
+	}
+	return res
+}
+
+// cloneValue 深拷贝条件值、字段载体和子查询，其他值原样返回
+func cloneValue(v any) any {
+	switch val := v.(type) {
+	case *sqlBuilder:
+		return val.Clone()
+	case []any:
+		return cloneSlice(val)
+	case [][]any:
+		res := make([][]any, len(val))
+		for i, row := range val {
+			res[i] = cloneSlice(row)
+		}
+		return res
+	case []string:
+		return append([]string(nil), val...)
+	case *funCarrier:
+		if val == nil {
+			return val
+		}
+		c := *val
+		c.Params = cloneSlice(val.Params)
+		return &c
+	case *colCarrier:
+		if val == nil {
+			return val
+		}
+		c := *val
+		return &c
+	case *literalCarrier:
+		if val == nil {
+			return val
+		}
+		c := *val
+		return &c
+	case *winCarrier:
+		if val == nil {
+			return val
+		}
+		c := *val
+		c.Params = cloneSlice(val.Params)
+		c.PartitionBy = append([]string(nil), val.PartitionBy...)
+		c.OrderBy = cloneValue(val.OrderBy).([][]any)
+		return &c
+	case *caseCarrier:
+		if val == nil {
+			return val
+		}
+		c := *val
+		c.Whens = make([]whenClause, len(val.Whens))
+		for i, w := range val.Whens {
+			c.Whens[i] = whenClause{When: cloneValue(w.When), Then: cloneValue(w.Then)}
+		}
+		c.ElseVal = cloneValue(val.ElseVal)
+		return &c
+	case *jsonFieldCarrier:
+		if val == nil {
+			return val
+		}
+		c := *val
+		return &c
+	case *valuesCarrier:
+		if val == nil {
+			return val
+		}
+		c := *val
+		return &c
+	}
+	return v
+}
+
+func cloneSlice(s []any) []any {
+	if s == nil {
+		return nil
+	}
+	res := make([]any, len(s))
+	for i, v := range s {
+		res[i] = cloneValue(v)
+	}
+	return res
+}
+
+func cloneMap(m map[string]bool) map[string]bool {
+	if m == nil {
+		return nil
+	}
+	res := make(map[string]bool, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
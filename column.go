@@ -1,9 +1,12 @@
 package sqlbuilder
 
+import "fmt"
+
 type funCarrier struct {
 	Alias  string        // 别名
 	Fn     string        // 函数
 	Params []any // 参数
+	err    error // 构造时被拒绝的原因
 }
 
 /**
@@ -16,13 +19,13 @@ type funCarrier struct {
 func Fn(fn, alias string, params ...any) *funCarrier {
 	// 函数名和别名是标识符，需要严格校验
 	if !isSafeIdentifier(fn) || !isSafeIdentifier(alias) {
-		return &funCarrier{}
+		return &funCarrier{err: fmt.Errorf("非法的函数名或别名: %s, %s", fn, alias)}
 	}
 	// 参数值用 hasIllegalStr 校验
 	for _, v := range params {
 		if val, ok := v.(string); ok {
 			if hasIllegalStr(val) {
-				return &funCarrier{}
+				return &funCarrier{err: fmt.Errorf("非法的函数参数: %s", val)}
 			}
 		}
 	}
@@ -37,6 +40,7 @@ type colCarrier struct {
 	TableAlias string // 表名
 	Field      string // 表字段
 	FieldAlias string // 字段别名
+	err        error  // 构造时被拒绝的原因
 }
 
 /**
@@ -47,9 +51,7 @@ func SField(tableAlias, field, fieldAlias string) *colCarrier {
 	temp := []string{tableAlias, field, fieldAlias}
 	for _, v := range temp {
 		if !isSafeIdentifier(v) {
-			tableAlias = ""
-			fieldAlias = ""
-			field = ""
+			return &colCarrier{err: fmt.Errorf("非法的字段: %s", v)}
 		}
 	}
 	return &colCarrier{
@@ -61,6 +63,7 @@ func SField(tableAlias, field, fieldAlias string) *colCarrier {
 
 type literalCarrier struct {
 	OriginVal string
+	err       error // 构造时被拒绝的原因
 }
 
 /**
@@ -69,7 +72,7 @@ type literalCarrier struct {
  */
 func Literal(originVal string) *literalCarrier {
 	if hasIllegalStr(originVal) {
-		originVal = ""
+		return &literalCarrier{err: fmt.Errorf("非法的原语: %s", originVal)}
 	}
 	return &literalCarrier{
 		OriginVal: originVal,
@@ -83,6 +86,7 @@ type winCarrier struct {
 	Params      []any
 	PartitionBy []string
 	OrderBy     [][]any
+	err         error // 构造时被拒绝的原因
 }
 
 /**
@@ -94,7 +98,7 @@ type winCarrier struct {
  */
 func WinFn(fn, alias string, params ...any) *winCarrier {
 	if !isSafeIdentifier(fn) || !isSafeIdentifier(alias) {
-		return &winCarrier{}
+		return &winCarrier{err: fmt.Errorf("非法的窗口函数名或别名: %s, %s", fn, alias)}
 	}
 	return &winCarrier{
 		Alias:  alias,
@@ -106,7 +110,7 @@ func WinFn(fn, alias string, params ...any) *winCarrier {
 // Partition 设置 PARTITION BY 字段
 func (w *winCarrier) Partition(fields ...string) *winCarrier {
 	if !isSafeIdentifierAny(fields...) {
-		return &winCarrier{}
+		return &winCarrier{err: fmt.Errorf("非法的 PARTITION BY 字段: %v", fields)}
 	}
 	w.PartitionBy = fields
 	return w
@@ -117,12 +121,12 @@ func (w *winCarrier) OrderByClause(order [][]any) *winCarrier {
 	for _, v := range order {
 		if len(v) >= 1 {
 			if s, ok := v[0].(string); ok && hasIllegalStr(s) {
-				return &winCarrier{}
+				return &winCarrier{err: fmt.Errorf("非法的窗口排序字段: %s", s)}
 			}
 		}
 		if len(v) >= 2 {
 			if s, ok := v[1].(string); ok && hasIllegalStr(s) {
-				return &winCarrier{}
+				return &winCarrier{err: fmt.Errorf("非法的窗口排序方向: %s", s)}
 			}
 		}
 	}
@@ -136,6 +140,7 @@ type caseCarrier struct {
 	CaseField string       // 简单 CASE 的字段
 	Whens     []whenClause
 	ElseVal   any
+	err       error // 构造时被拒绝的原因
 }
 
 // whenClause WHEN ... THEN 子句
@@ -150,7 +155,7 @@ type whenClause struct {
  */
 func CaseWhen(alias string) *caseCarrier {
 	if !isSafeIdentifier(alias) {
-		return &caseCarrier{}
+		return &caseCarrier{err: fmt.Errorf("非法的 CASE WHEN 别名: %s", alias)}
 	}
 	return &caseCarrier{
 		Alias: alias,
@@ -160,7 +165,7 @@ func CaseWhen(alias string) *caseCarrier {
 // SimpleCase 设置简单 CASE 的字段
 func (c *caseCarrier) SimpleCase(field string) *caseCarrier {
 	if !isSafeIdentifier(field) {
-		return &caseCarrier{}
+		return &caseCarrier{err: fmt.Errorf("非法的 CASE 字段: %s", field)}
 	}
 	c.CaseField = field
 	return c
@@ -184,6 +189,7 @@ type jsonFieldCarrier struct {
 	Field      string
 	Arrow      string // "->" or "->>"
 	Path       string // "$.key"
+	err        error  // 构造时被拒绝的原因
 }
 
 /**
@@ -194,7 +200,7 @@ type jsonFieldCarrier struct {
 func JsonField(tableAlias, field, arrow, path string) *jsonFieldCarrier {
 	for _, v := range []string{tableAlias, field, arrow, path} {
 		if !isSafeIdentifier(v) {
-			return &jsonFieldCarrier{}
+			return &jsonFieldCarrier{err: fmt.Errorf("非法的 JSON 字段参数: %s", v)}
 		}
 	}
 	return &jsonFieldCarrier{
@@ -204,3 +210,51 @@ func JsonField(tableAlias, field, arrow, path string) *jsonFieldCarrier {
 		Path:       path,
 	}
 }
+
+// render 按方言渲染 JSON 字段访问表达式
+func (j *jsonFieldCarrier) render(d Dialect) string {
+	if j.Field == "" {
+		return ""
+	}
+	if j.TableAlias != "" {
+		return fmt.Sprintf("%s.%s%s'%s'", d.Quote(j.TableAlias), d.Quote(j.Field), j.Arrow, j.Path)
+	}
+	return fmt.Sprintf("%s%s'%s'", d.Quote(j.Field), j.Arrow, j.Path)
+}
+
+type valuesCarrier struct {
+	Field string
+	err   error // 构造时被拒绝的原因
+}
+
+/**
+ * 引用 INSERT 中待插入的值，即 VALUES(field)，用于 ON DUPLICATE KEY UPDATE
+ * 如 OnDuplicateKey(map[string]any{"cnt": []any{"cnt", "+", Values("cnt")}})
+ */
+func Values(field string) *valuesCarrier {
+	if !isSafeIdentifier(field) {
+		return &valuesCarrier{err: fmt.Errorf("非法的字段: %s", field)}
+	}
+	return &valuesCarrier{Field: field}
+}
+
+// carrierErr 返回载体构造时被拒绝的原因，非载体或合法载体返回 nil
+func carrierErr(v any) error {
+	switch c := v.(type) {
+	case *funCarrier:
+		return c.err
+	case *colCarrier:
+		return c.err
+	case *literalCarrier:
+		return c.err
+	case *winCarrier:
+		return c.err
+	case *caseCarrier:
+		return c.err
+	case *jsonFieldCarrier:
+		return c.err
+	case *valuesCarrier:
+		return c.err
+	}
+	return nil
+}
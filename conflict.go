@@ -0,0 +1,233 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// conflictClause INSERT ... ON CONFLICT 子句（PostgreSQL / SQLite）
+type conflictClause struct {
+	columns    []string       // 冲突目标列
+	constraint string         // 冲突目标约束名（ON CONSTRAINT，仅 PostgreSQL）
+	doNothing  bool           // DO NOTHING
+	updates    map[string]any // DO UPDATE SET
+	where      *Where         // DO UPDATE ... WHERE
+}
+
+// conflictBuilder 用于链式设置 ON CONFLICT 的动作
+type conflictBuilder struct {
+	b      *sqlBuilder
+	clause *conflictClause
+}
+
+// OnConflict 设置 INSERT ... ON CONFLICT (columns...) 子句，需继续调用 DoNothing 或 DoUpdate
+// 不传列时只能配合 DoNothing 使用
+func (b *sqlBuilder) OnConflict(columns ...string) *conflictBuilder {
+	c := &conflictClause{}
+	if !isSafeIdentifierAny(columns...) {
+		b.err = fmt.Errorf("非法的 ON CONFLICT 列名")
+	} else {
+		c.columns = columns
+	}
+	return &conflictBuilder{b: b, clause: c}
+}
+
+// OnConflictConstraint 设置 INSERT ... ON CONFLICT ON CONSTRAINT name 子句（PostgreSQL）
+func (b *sqlBuilder) OnConflictConstraint(name string) *conflictBuilder {
+	c := &conflictClause{}
+	if !isSafeIdentifier(name) {
+		b.err = fmt.Errorf("非法的约束名: %s", name)
+	} else {
+		c.constraint = name
+	}
+	return &conflictBuilder{b: b, clause: c}
+}
+
+// DoNothing 冲突时忽略本行
+func (c *conflictBuilder) DoNothing() *sqlBuilder {
+	c.clause.doNothing = true
+	c.b.conflict = c.clause
+	return c.b
+}
+
+// DoUpdate 冲突时更新，值可以是普通值（占位符绑定）、Excluded(col)、SField 或 Literal
+func (c *conflictBuilder) DoUpdate(updates map[string]any) *sqlBuilder {
+	return c.DoUpdateWhere(updates)
+}
+
+// DoUpdateWhere 冲突时满足条件才更新，条件参数格式与 WhereAnd 相同
+// 如 DoUpdateWhere(updates, "version", "<", Excluded("version"))
+func (c *conflictBuilder) DoUpdateWhere(updates map[string]any, args ...any) *sqlBuilder {
+	b := c.b
+	if len(updates) == 0 {
+		b.err = errors.New("ON CONFLICT DO UPDATE 的更新字段不能为空")
+		return b
+	}
+	if err := validateMapKeys(updates); err != nil {
+		b.err = err
+		return b
+	}
+	c.clause.updates = updates
+	if len(args) > 0 {
+		if err := checkConditionArgs("ON CONFLICT", args...); err != nil {
+			b.err = err
+			return b
+		}
+		if errs := skippedConditions("ON CONFLICT", args...); len(errs) > 0 {
+			b.err = errs[0]
+			return b
+		}
+		val, ok := argsMap[len(args)]
+		if !ok {
+			b.err = fmt.Errorf("不支持的 ON CONFLICT 条件参数数量: %d", len(args))
+			return b
+		}
+		// INSERT 语句中没有表别名，条件直接引用表名
+		c.clause.where = &Where{
+			tableName:     b.tableName,
+			alias:         b.tableName,
+			groupWhere:    make([]GroupWhere, 0),
+			assembleWhere: [][]GroupWhere{val.ParseArgs("and", args...)},
+		}
+	}
+	b.conflict = c.clause
+	return b
+}
+
+// Excluded 引用 ON CONFLICT 中待插入行的列，即 excluded.col
+func Excluded(column string) *colCarrier {
+	return SField("excluded", column, "")
+}
+
+// renderConflict 渲染 ON CONFLICT 子句，占位符统一为 ?
+func (b *sqlBuilder) renderConflict() (string, []any, error) {
+	c := b.conflict
+	if err := b.require(FeatureOnConflict, "on conflict"); err != nil {
+		return "", nil, err
+	}
+	var sb strings.Builder
+	sb.WriteString("on conflict")
+	if c.constraint != "" {
+		if err := b.require(FeatureOnConflictConstraint, "on conflict on constraint"); err != nil {
+			return "", nil, err
+		}
+		sb.WriteString(" on constraint " + b.quote(c.constraint))
+	} else if len(c.columns) > 0 {
+		cols := make([]string, len(c.columns))
+		for i, col := range c.columns {
+			cols[i] = b.quote(col)
+		}
+		sb.WriteString(" (" + strings.Join(cols, ", ") + ")")
+	}
+	if c.doNothing {
+		sb.WriteString(" do nothing")
+		return sb.String(), nil, nil
+	}
+	if c.constraint == "" && len(c.columns) == 0 {
+		return "", nil, errors.New("ON CONFLICT DO UPDATE 必须指定冲突列或约束")
+	}
+	var args []any
+	var sets []string
+	for _, k := range b.orderedKeys(c.updates) {
+		// INSERT 语句中没有表别名，字段运算以表名限定，与 excluded 区分
+		expr, exprArgs, err := b.renderSetValue(c.updates[k], b.tableName)
+		if err != nil {
+			return "", nil, fmt.Errorf("ON CONFLICT 字段 %s: %w", k, err)
+		}
+		sets = append(sets, fmt.Sprintf("%s = %s", b.quote(k), expr))
+		args = append(args, exprArgs...)
+	}
+	sb.WriteString(" do update set " + strings.Join(sets, ", "))
+	if c.where != nil {
+		whStr, whArgs, err := b.parseCond(c.where)
+		if err != nil {
+			return "", nil, err
+		}
+		if whStr != "" {
+			sb.WriteString(" where " + whStr)
+			args = append(args, whArgs...)
+		}
+	}
+	return sb.String(), args, nil
+}
+
+// renderSetValue 渲染 SET 右侧的值：列引用、VALUES(col) 和原语直接内联，
+// []any{字段名, 运算符, 值} 渲染为字段运算，其他值使用占位符
+// table 非空时用于限定字段运算中的字段：ON CONFLICT DO UPDATE 中未限定的列与 excluded 的列有歧义（PostgreSQL 报错），
+// 为空时表示 ON DUPLICATE KEY UPDATE，字段运算和 VALUES(col) 只在支持该语法的方言中可用
+func (b *sqlBuilder) renderSetValue(v any, table string) (string, []any, error) {
+	if err := carrierErr(v); err != nil {
+		return "", nil, err
+	}
+	switch val := v.(type) {
+	case *colCarrier:
+		if val.TableAlias != "" {
+			return b.quoteCol(val.TableAlias, val.Field), nil, nil
+		}
+		return b.quote(val.Field), nil, nil
+	case *literalCarrier:
+		return val.OriginVal, nil, nil
+	case *valuesCarrier:
+		if table != "" || !b.getDialect().Supports(FeatureOnDuplicateKey) {
+			return "", nil, fmt.Errorf("%s 方言不支持 values(%s)，ON CONFLICT 中请使用 Excluded", b.getDialect().Name(), val.Field)
+		}
+		return fmt.Sprintf("values(%s)", b.quote(val.Field)), nil, nil
+	case []any:
+		if table == "" && !b.getDialect().Supports(FeatureOnDuplicateKey) {
+			return "", nil, fmt.Errorf("%s 方言不支持 ON DUPLICATE KEY UPDATE 字段运算", b.getDialect().Name())
+		}
+		if len(val) != 3 {
+			return "", nil, errors.New("运算表达式格式错误，需要 []any{字段名, 运算符, 值}")
+		}
+		field, ok := val[0].(string)
+		if !ok || !isSafeIdentifier(field) {
+			return "", nil, fmt.Errorf("非法的运算字段: %v", val[0])
+		}
+		op, _ := val[1].(string)
+		switch op {
+		case "+", "-", "*", "/":
+		default:
+			return "", nil, fmt.Errorf("不支持的运算符: %v", val[1])
+		}
+		right, args, err := b.renderSetValue(val[2], table)
+		if err != nil {
+			return "", nil, err
+		}
+		left := b.quote(field)
+		if table != "" {
+			left = b.quoteCol(table, field)
+		}
+		return fmt.Sprintf("%s %s %s", left, op, right), args, nil
+	}
+	return "?", []any{v}, nil
+}
+
+// insertSuffix 渲染 INSERT 末尾的 ON DUPLICATE KEY UPDATE 或 ON CONFLICT 子句及 RETURNING 子句，带前导空格
+// columns 为本次插入的列，用于 OnDuplicateKeyUpdateAll
+func (b *sqlBuilder) insertSuffix(columns []string) (string, []any, error) {
+	onDuplicate := len(b.onDuplicateUpdates) > 0 || b.onDuplicateAll
+	if onDuplicate && b.conflict != nil {
+		return "", nil, errors.New("ON DUPLICATE KEY UPDATE 与 ON CONFLICT 不能同时使用")
+	}
+	var suffix string
+	var args []any
+	if b.conflict != nil {
+		sql, conflictArgs, err := b.renderConflict()
+		if err != nil {
+			return "", nil, err
+		}
+		suffix, args = " "+sql, conflictArgs
+	} else if onDuplicate {
+		sql, dupArgs, err := b.renderOnDuplicateKey(columns)
+		if err != nil {
+			return "", nil, err
+		}
+		suffix, args = sql, dupArgs
+	}
+	suffix, err := b.appendReturning(suffix, FeatureReturning)
+	if err != nil {
+		return "", nil, err
+	}
+	return suffix, args, nil
+}
@@ -0,0 +1,223 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Feature 方言特性
+type Feature uint32
+
+const (
+	// FeatureSqlHint SELECT 提示（SQL_NO_CACHE 等）
+	FeatureSqlHint Feature = 1 << iota
+	// FeatureIndexHint USE/FORCE/IGNORE INDEX 索引提示
+	FeatureIndexHint
+	// FeatureStraightJoin STRAIGHT_JOIN
+	FeatureStraightJoin
+	// FeatureFullJoin 原生 FULL OUTER JOIN，不支持时用 LEFT JOIN 模拟
+	FeatureFullJoin
+	// FeatureWithRollup GROUP BY ... WITH ROLLUP
+	FeatureWithRollup
+	// FeatureForUpdate FOR UPDATE
+	FeatureForUpdate
+	// FeatureLockInShareMode LOCK IN SHARE MODE
+	FeatureLockInShareMode
+	// FeatureRecursiveKeyword 递归 CTE 需要 RECURSIVE 关键字
+	FeatureRecursiveKeyword
+	// FeatureUnionParens UNION 成员允许用括号包裹
+	FeatureUnionParens
+	// FeatureOffsetFetch 分页使用 OFFSET ... FETCH 语法，必须带 ORDER BY
+	FeatureOffsetFetch
+	// FeatureInsertIgnore INSERT IGNORE
+	FeatureInsertIgnore
+	// FeatureReplace REPLACE INTO
+	FeatureReplace
+	// FeatureInsertSet INSERT ... SET
+	FeatureInsertSet
+	// FeatureOnDuplicateKey ON DUPLICATE KEY UPDATE
+	FeatureOnDuplicateKey
+	// FeatureQualifiedSet UPDATE 的 SET 列允许带表别名
+	FeatureQualifiedSet
+	// FeatureUpdateFrom UPDATE alias SET ... FROM table AS alias 语法
+	FeatureUpdateFrom
+	// FeatureUpdateJoin UPDATE table JOIN ... SET 语法
+	FeatureUpdateJoin
+	// FeatureDeleteJoin DELETE alias FROM table AS alias [JOIN ...] 语法
+	FeatureDeleteJoin
+	// FeatureUpdateOrderLimit UPDATE/DELETE 支持 ORDER BY 和 LIMIT
+	FeatureUpdateOrderLimit
+	// FeatureTruncate TRUNCATE TABLE
+	FeatureTruncate
+	// FeatureFindInSet FIND_IN_SET 函数
+	FeatureFindInSet
+	// FeatureOnConflict INSERT ... ON CONFLICT DO NOTHING / DO UPDATE
+	FeatureOnConflict
+	// FeatureOnConflictConstraint ON CONFLICT ON CONSTRAINT name
+	FeatureOnConflictConstraint
+	// FeatureReturning INSERT/DELETE ... RETURNING
+	FeatureReturning
+	// FeatureUpdateReturning UPDATE ... RETURNING
+	FeatureUpdateReturning
+)
+
+// Dialect SQL 方言，控制标识符引用、占位符、分页语法以及可用特性
+type Dialect interface {
+	// Name 方言名称
+	Name() string
+	// Quote 引用标识符（表名、列名、别名等）
+	Quote(ident string) string
+	// Placeholder 第 n 个（从 1 开始）位置参数的占位符
+	Placeholder(n int) string
+	// Limit 渲染分页子句，offset < 0 表示不带偏移量
+	Limit(offset, size int64) string
+	// Supports 是否支持某项特性
+	Supports(f Feature) bool
+}
+
+var (
+	// MySQL 方言（默认）
+	MySQL Dialect = &mysqlDialect{}
+	// MariaDB 方言，在 MySQL 基础上支持 INSERT/DELETE ... RETURNING（10.5+）
+	MariaDB Dialect = &mariaDBDialect{}
+	// Postgres PostgreSQL 方言
+	Postgres Dialect = &postgresDialect{}
+	// SQLite SQLite 方言
+	SQLite Dialect = &sqliteDialect{}
+	// SQLServer SQL Server 方言
+	SQLServer Dialect = &sqlServerDialect{}
+)
+
+var defaultDialect = MySQL
+
+// SetDefaultDialect 设置全局默认方言，未通过 Dialect 指定方言的 builder 使用该方言
+func SetDefaultDialect(d Dialect) {
+	if d == nil {
+		d = MySQL
+	}
+	defaultDialect = d
+}
+
+type mysqlDialect struct{}
+
+func (d *mysqlDialect) Name() string { return "mysql" }
+
+func (d *mysqlDialect) Quote(ident string) string { return "`" + ident + "`" }
+
+func (d *mysqlDialect) Placeholder(n int) string { return "?" }
+
+func (d *mysqlDialect) Limit(offset, size int64) string {
+	if offset < 0 {
+		return fmt.Sprintf("limit %d", size)
+	}
+	return fmt.Sprintf("limit %d,%d", offset, size)
+}
+
+func (d *mysqlDialect) Supports(f Feature) bool {
+	return f&(FeatureSqlHint|FeatureIndexHint|FeatureStraightJoin|FeatureWithRollup|
+		FeatureForUpdate|FeatureLockInShareMode|FeatureRecursiveKeyword|FeatureUnionParens|
+		FeatureInsertIgnore|FeatureReplace|FeatureInsertSet|FeatureOnDuplicateKey|
+		FeatureQualifiedSet|FeatureUpdateJoin|FeatureDeleteJoin|FeatureUpdateOrderLimit|
+		FeatureTruncate|FeatureFindInSet) == f
+}
+
+type mariaDBDialect struct {
+	mysqlDialect
+}
+
+func (d *mariaDBDialect) Name() string { return "mariadb" }
+
+func (d *mariaDBDialect) Supports(f Feature) bool {
+	return d.mysqlDialect.Supports(f &^ FeatureReturning)
+}
+
+type postgresDialect struct{}
+
+func (d *postgresDialect) Name() string { return "postgres" }
+
+func (d *postgresDialect) Quote(ident string) string { return `"` + ident + `"` }
+
+func (d *postgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (d *postgresDialect) Limit(offset, size int64) string {
+	if offset <= 0 {
+		return fmt.Sprintf("limit %d", size)
+	}
+	return fmt.Sprintf("limit %d offset %d", size, offset)
+}
+
+func (d *postgresDialect) Supports(f Feature) bool {
+	return f&(FeatureFullJoin|FeatureForUpdate|FeatureRecursiveKeyword|FeatureUnionParens|
+		FeatureTruncate|FeatureOnConflict|FeatureOnConflictConstraint|FeatureReturning|FeatureUpdateReturning) == f
+}
+
+type sqliteDialect struct{}
+
+func (d *sqliteDialect) Name() string { return "sqlite" }
+
+func (d *sqliteDialect) Quote(ident string) string { return `"` + ident + `"` }
+
+func (d *sqliteDialect) Placeholder(n int) string { return "?" }
+
+func (d *sqliteDialect) Limit(offset, size int64) string {
+	if offset <= 0 {
+		return fmt.Sprintf("limit %d", size)
+	}
+	return fmt.Sprintf("limit %d offset %d", size, offset)
+}
+
+func (d *sqliteDialect) Supports(f Feature) bool {
+	return f&(FeatureFullJoin|FeatureRecursiveKeyword|FeatureReplace|FeatureOnConflict|
+		FeatureReturning|FeatureUpdateReturning) == f
+}
+
+type sqlServerDialect struct{}
+
+func (d *sqlServerDialect) Name() string { return "sqlserver" }
+
+func (d *sqlServerDialect) Quote(ident string) string { return "[" + ident + "]" }
+
+func (d *sqlServerDialect) Placeholder(n int) string { return "@p" + strconv.Itoa(n) }
+
+func (d *sqlServerDialect) Limit(offset, size int64) string {
+	if offset < 0 {
+		offset = 0
+	}
+	return fmt.Sprintf("offset %d rows fetch next %d rows only", offset, size)
+}
+
+func (d *sqlServerDialect) Supports(f Feature) bool {
+	return f&(FeatureFullJoin|FeatureWithRollup|FeatureUnionParens|FeatureOffsetFetch|
+		FeatureUpdateFrom|FeatureDeleteJoin|FeatureTruncate) == f
+}
+
+// rebind 将内部统一使用的 ? 占位符替换为方言占位符，跳过引号内的内容
+func rebind(d Dialect, query string) string {
+	if d.Placeholder(1) == "?" || !strings.Contains(query, "?") {
+		return query
+	}
+	var sb strings.Builder
+	sb.Grow(len(query) + 16)
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '?':
+			n++
+			sb.WriteString(d.Placeholder(n))
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
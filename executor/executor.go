@@ -0,0 +1,200 @@
+// Package executor 执行 sqlbuilder 构建的 SQL，并按 db tag 将结果扫描到结构体
+//
+// 执行和扫描只依赖 database/sql，*sql.DB、*sql.Tx、*sql.Conn 以及 *sqlx.DB、*sqlx.Tx 都满足 DB 接口：
+//
+//	var users []User
+//	err := executor.Select(ctx, db, &users, sqlbuilder.From("user").WhereAnd("status", 1))
+//
+// 泛型查询 Typed[T] 基于 sqlbuilder 构建；sqlbuilder 本身不依赖本包
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// ErrStaleObject 乐观锁更新没有影响任何行：数据已被其他操作修改（版本号不匹配）或已被删除
+var ErrStaleObject = errors.New("数据已被修改或删除，版本号不匹配")
+
+// DB 执行 SQL 的数据库连接或事务
+type DB interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Selecter 可构建 SELECT 的 builder
+type Selecter interface {
+	BuildSelect() (string, []any, error)
+}
+
+// Updater 可构建 UPDATE 的 builder
+type Updater interface {
+	BuildMapUpdate(option map[string]any) (string, []any, error)
+}
+
+// StructUpdater 可构建结构体 UPDATE 的 builder
+type StructUpdater interface {
+	BuildStructUpdate(entity any) (string, []any, error)
+}
+
+// Deleter 可构建 DELETE 的 builder
+type Deleter interface {
+	BuildDelete() (string, []any, error)
+}
+
+// dbTagger 提供结构体映射使用的 tag 名，对应 sqlbuilder 的 SetDbTag
+type dbTagger interface {
+	GetDbTag() string
+}
+
+// versioner 提供乐观锁的版本号字段，对应 sqlbuilder 的 Versioned
+type versioner interface {
+	VersionField() string
+}
+
+// versionOf 返回 builder 配置的版本号字段，未配置时为空
+func versionOf(b any) string {
+	if v, ok := b.(versioner); ok {
+		return v.VersionField()
+	}
+	return ""
+}
+
+// tagOf 返回 builder 配置的 db tag，未配置时为 db
+func tagOf(b any) string {
+	if t, ok := b.(dbTagger); ok && t.GetDbTag() != "" {
+		return t.GetDbTag()
+	}
+	return "db"
+}
+
+// Query 执行 builder 的 SELECT，返回的 *sql.Rows 需由调用方关闭
+func Query(ctx context.Context, db DB, b Selecter) (*sql.Rows, error) {
+	query, args, err := b.BuildSelect()
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryContext(ctx, query, args...)
+}
+
+// Get 执行 builder 的 SELECT 并将第一行扫描到 dest，没有结果时返回 sql.ErrNoRows
+// dest 为结构体指针或基础类型指针
+func Get(ctx context.Context, db DB, dest any, b Selecter) error {
+	rows, err := Query(ctx, db, b)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if err := ScanOne(rows, dest, tagOf(b)); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// Select 执行 builder 的 SELECT 并将所有行扫描到 dest
+// dest 为结构体切片指针、结构体指针切片指针或基础类型切片指针
+func Select(ctx context.Context, db DB, dest any, b Selecter) error {
+	rows, err := Query(ctx, db, b)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return ScanAll(rows, dest, tagOf(b))
+}
+
+// Exec 执行 SQL，通常传入 Build* 的结果
+func Exec(ctx context.Context, db DB, query string, args ...any) (sql.Result, error) {
+	return db.ExecContext(ctx, query, args...)
+}
+
+// Update 执行 builder 的 BuildMapUpdate，开启乐观锁且没有影响任何行时返回 ErrStaleObject
+func Update(ctx context.Context, db DB, b Updater, values map[string]any) (sql.Result, error) {
+	query, args, err := b.BuildMapUpdate(values)
+	if err != nil {
+		return nil, err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil || versionOf(b) == "" {
+		return res, err
+	}
+	return res, checkStale(res)
+}
+
+// UpdateStruct 执行 builder 的 BuildStructUpdate
+// entity 含版本号字段（Versioned 或 `db:"version,version"`）时，没有影响任何行返回 ErrStaleObject，
+// 更新成功后 entity 的版本号加 1
+func UpdateStruct(ctx context.Context, db DB, b StructUpdater, entity any) (sql.Result, error) {
+	query, args, err := b.BuildStructUpdate(entity)
+	if err != nil {
+		return nil, err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return res, err
+	}
+	field, ok := versionFieldOf(reflect.ValueOf(entity).Elem(), tagOf(b), versionOf(b))
+	if !ok {
+		return res, nil
+	}
+	if err := checkStale(res); err != nil {
+		return res, err
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(field.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(field.Uint() + 1)
+	}
+	return res, nil
+}
+
+// checkStale 乐观锁更新没有影响任何行时返回 ErrStaleObject
+func checkStale(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrStaleObject
+	}
+	return nil
+}
+
+// versionFieldOf 查找结构体的版本号字段：tag 带 version 选项，或列名为 name
+func versionFieldOf(v reflect.Value, tag, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if fv, ok := versionFieldOf(v.Field(i), tag, name); ok {
+				return fv, true
+			}
+			continue
+		}
+		opts := strings.Split(field.Tag.Get(tag), ",")
+		if opts[0] == "" || opts[0] == "-" {
+			continue
+		}
+		if opts[0] == name {
+			return v.Field(i), true
+		}
+		for _, opt := range opts[1:] {
+			if strings.TrimSpace(opt) == "version" {
+				return v.Field(i), true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Delete 执行 builder 的 BuildDelete
+func Delete(ctx context.Context, db DB, b Deleter) (sql.Result, error) {
+	query, args, err := b.BuildDelete()
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, query, args...)
+}
@@ -0,0 +1,478 @@
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Ifkl/sqlbuilder"
+)
+
+// ========== Fake Driver ==========
+
+// fakeDriver 记录执行的 SQL，并返回预设的结果集
+type fakeDriver struct {
+	mu       sync.Mutex
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+	query    string
+	args     []driver.Value
+	// queue 按顺序作为之后查询的结果集，取完后使用 columns、rows
+	queue   []fakeRows
+	queries []string
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+func (d *fakeDriver) set(columns []string, rows ...[]driver.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.columns, d.rows = columns, rows
+	d.queue, d.queries = nil, nil
+}
+
+// enqueue 预设下一次查询的结果集，优先于 set 设置的结果集
+func (d *fakeDriver) enqueue(columns []string, rows ...[]driver.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queue = append(d.queue, fakeRows{columns: columns, rows: rows})
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeConn) Commit() error             { return nil }
+func (c *fakeConn) Rollback() error           { return nil }
+
+func (c *fakeConn) record(query string, args []driver.NamedValue) {
+	c.d.query = query
+	c.d.args = c.d.args[:0]
+	for _, a := range args {
+		c.d.args = append(c.d.args, a.Value)
+	}
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.record(query, args)
+	c.d.queries = append(c.d.queries, query)
+	if len(c.d.queue) > 0 {
+		r := c.d.queue[0]
+		c.d.queue = c.d.queue[1:]
+		return &r, nil
+	}
+	return &fakeRows{columns: c.d.columns, rows: c.d.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.record(query, args)
+	return driver.RowsAffected(c.d.affected), nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	i       int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
+
+var fake = &fakeDriver{}
+
+func init() {
+	sql.Register("fake", fake)
+}
+
+func openFake(t *testing.T) *sql.DB {
+	db, err := sql.Open("fake", "")
+	if err != nil {
+		t.Fatalf("open fake db: %v", err)
+	}
+	return db
+}
+
+// ========== Executor Tests ==========
+
+type Base struct {
+	Id int64 `db:"id"`
+}
+
+type User struct {
+	Base
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+	Note string
+}
+
+func TestSelect_ScansStructsWithEmbedded(t *testing.T) {
+	db := openFake(t)
+	fake.set([]string{"id", "name", "age"},
+		[]driver.Value{int64(1), "a", int64(18)},
+		[]driver.Value{int64(2), "b", int64(20)},
+	)
+	var users []User
+	b := sqlbuilder.From("user").WhereAnd("age", ">", 10)
+	if err := Select(context.Background(), db, &users, b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 2 || users[1].Id != 2 || users[1].Name != "b" || users[1].Age != 20 {
+		t.Errorf("unexpected users: %+v", users)
+	}
+	if fake.query != "select `user`.* from `user` as `user` where `user`.`age` > ?" || fake.args[0] != int64(10) {
+		t.Errorf("unexpected query: %s %v", fake.query, fake.args)
+	}
+
+	var ptrs []*User
+	if err := Select(context.Background(), db, &ptrs, b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ptrs) != 2 || ptrs[0].Name != "a" {
+		t.Errorf("unexpected users: %+v", ptrs)
+	}
+}
+
+func TestGet_StructScalarAndNoRows(t *testing.T) {
+	db := openFake(t)
+	fake.set([]string{"id", "name"}, []driver.Value{int64(7), "x"})
+	var u User
+	if err := Get(context.Background(), db, &u, sqlbuilder.From("user").WhereAnd("id", 7)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u.Id != 7 || u.Name != "x" {
+		t.Errorf("unexpected user: %+v", u)
+	}
+
+	fake.set([]string{"_count"}, []driver.Value{int64(42)})
+	var count int64
+	if err := Get(context.Background(), db, &count, sqlbuilder.From("user")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 42 {
+		t.Errorf("expected 42, got %d", count)
+	}
+
+	fake.set([]string{"id"})
+	if err := Get(context.Background(), db, &u, sqlbuilder.From("user")); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	fake.set([]string{"unknown"}, []driver.Value{int64(1)})
+	if err := Get(context.Background(), db, &u, sqlbuilder.From("user")); err == nil {
+		t.Error("expected error for unmapped column")
+	}
+}
+
+func TestSelect_CustomDbTag(t *testing.T) {
+	type Item struct {
+		Id   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	db := openFake(t)
+	fake.set([]string{"id", "name"}, []driver.Value{int64(1), "a"})
+	var items []Item
+	if err := Select(context.Background(), db, &items, sqlbuilder.From("item").SetDbTag("json")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "a" {
+		t.Errorf("unexpected items: %+v", items)
+	}
+}
+
+func TestExec_UpdateAndDelete(t *testing.T) {
+	db := openFake(t)
+	fake.affected = 3
+	defer func() { fake.affected = 0 }()
+
+	res, err := Update(context.Background(), db, sqlbuilder.From("user").WhereAnd("id", 1), map[string]any{"name": "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n != 3 {
+		t.Errorf("expected 3 rows affected, got %d", n)
+	}
+	if fake.query != "update `user` as `user` set `user`.`name` = ? where `user`.`id` = ?" {
+		t.Errorf("unexpected query: %s", fake.query)
+	}
+
+	if _, err := Delete(context.Background(), db, sqlbuilder.From("user")); err == nil {
+		t.Error("expected build error for DELETE without WHERE")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	sqlStr, args := sqlbuilder.From("user").BuildMapInsert(map[string]any{"name": "a"})
+	if _, err := Exec(context.Background(), tx, sqlStr, args...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.query != sqlStr {
+		t.Errorf("unexpected query: %s", fake.query)
+	}
+	_ = tx.Commit()
+}
+
+// ========== Scanner Tests ==========
+
+type Audit struct {
+	CreatedBy string `db:"created_by"`
+}
+
+type Dept struct {
+	Id   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+type Employee struct {
+	*Audit
+	Id       int64          `db:"id"`
+	Name     string         `db:"name"`
+	Nickname *string        `db:"nickname"`
+	Email    sql.NullString `db:"email"`
+	Dept     Dept           `db:"d"`
+	Manager  *Dept          `db:"m"`
+}
+
+func TestScan_NestedPointersAndNullTypes(t *testing.T) {
+	db := openFake(t)
+	fake.set([]string{"id", "name", "nickname", "email", "created_by", "d.id", "d.name", "m.name"},
+		[]driver.Value{int64(1), "a", nil, "a@x.com", "root", int64(10), "dev", "boss"},
+		[]driver.Value{int64(2), "b", "bb", nil, nil, int64(11), "ops", nil},
+	)
+	b := sqlbuilder.From("employee").As("e").
+		Select("id", "name", "nickname", "email", "created_by",
+			sqlbuilder.SField("d", "id", "d.id"), sqlbuilder.SField("d", "name", "d.name"),
+			sqlbuilder.SField("m", "name", "m.name")).
+		LeftJoin("dept", "d", "dept_id", "id").
+		LeftJoin("dept", "m", "manager_dept_id", "id")
+	var list []Employee
+	if err := Select(context.Background(), db, &list, b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(list))
+	}
+	first, second := list[0], list[1]
+	if first.Nickname != nil || second.Nickname == nil || *second.Nickname != "bb" {
+		t.Errorf("unexpected nickname: %v %v", first.Nickname, second.Nickname)
+	}
+	if !first.Email.Valid || first.Email.String != "a@x.com" || second.Email.Valid {
+		t.Errorf("unexpected email: %+v %+v", first.Email, second.Email)
+	}
+	if first.Audit == nil || first.CreatedBy != "root" {
+		t.Errorf("embedded pointer struct not filled: %+v", first.Audit)
+	}
+	if first.Dept.Id != 10 || first.Dept.Name != "dev" || second.Dept.Name != "ops" {
+		t.Errorf("unexpected nested dept: %+v %+v", first.Dept, second.Dept)
+	}
+	if first.Manager == nil || first.Manager.Name != "boss" {
+		t.Errorf("unexpected manager: %+v", first.Manager)
+	}
+}
+
+func TestScan_AliasPrefixedColumns(t *testing.T) {
+	db := openFake(t)
+	// 带表别名的列找不到完整路径时按列名匹配
+	fake.set([]string{"u.id", "u.name"}, []driver.Value{int64(3), "c"})
+	var u User
+	if err := Get(context.Background(), db, &u, sqlbuilder.From("user")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u.Id != 3 || u.Name != "c" {
+		t.Errorf("unexpected user: %+v", u)
+	}
+}
+
+func TestScan_WithoutBuilder(t *testing.T) {
+	db := openFake(t)
+	fake.set([]string{"id", "name"}, []driver.Value{int64(1), "a"}, []driver.Value{int64(2), "b"})
+	rows, err := db.Query("select id, name from dept")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+	var depts []*Dept
+	if err := ScanAll(rows, &depts, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(depts) != 2 || depts[1].Name != "b" {
+		t.Errorf("unexpected depts: %+v", depts)
+	}
+	if _, ok := fieldCache.Load(fieldCacheKey{t: reflect.TypeOf(Dept{}), tag: "db"}); !ok {
+		t.Error("field mapping should be cached")
+	}
+}
+
+func TestScan_NilPointerStructWhenAllNull(t *testing.T) {
+	db := openFake(t)
+	fake.set([]string{"id", "name", "m.id", "m.name"},
+		[]driver.Value{int64(1), "a", nil, nil},
+	)
+	var e Employee
+	if err := Get(context.Background(), db, &e, sqlbuilder.From("employee")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Manager != nil || e.Audit != nil {
+		t.Errorf("pointer structs should stay nil when all columns are NULL: %+v %+v", e.Manager, e.Audit)
+	}
+}
+
+// ========== Optimistic Lock Tests ==========
+
+type Document struct {
+	Id      int64  `db:"id,pk"`
+	Title   string `db:"title"`
+	Version int    `db:"version,version"`
+}
+
+func TestUpdateStruct_StaleObject(t *testing.T) {
+	db := openFake(t)
+	defer func() { fake.affected = 0 }()
+
+	doc := &Document{Id: 1, Title: "t", Version: 3}
+	fake.affected = 1
+	if _, err := UpdateStruct(context.Background(), db, sqlbuilder.From("document").WhereAnd("id", 1), doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Version != 4 {
+		t.Errorf("expected version to be bumped to 4, got %d", doc.Version)
+	}
+	if fake.args[len(fake.args)-1] != int64(3) {
+		t.Errorf("expected current version in condition, got %v", fake.args)
+	}
+
+	fake.affected = 0
+	if _, err := UpdateStruct(context.Background(), db, sqlbuilder.From("document").WhereAnd("id", 1), doc); !errors.Is(err, ErrStaleObject) {
+		t.Errorf("expected ErrStaleObject, got %v", err)
+	}
+	if doc.Version != 4 {
+		t.Errorf("version should not change on stale update, got %d", doc.Version)
+	}
+
+	// 未开启乐观锁时 0 行不视为错误
+	if _, err := UpdateStruct(context.Background(), db, sqlbuilder.From("user").WhereAnd("id", 1), &User{Name: "a"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdate_VersionedMapStaleObject(t *testing.T) {
+	db := openFake(t)
+	b := sqlbuilder.From("document").Versioned("version").WhereAnd("id", 1)
+	_, err := Update(context.Background(), db, b, map[string]any{"title": "t", "version": 3})
+	if !errors.Is(err, ErrStaleObject) {
+		t.Errorf("expected ErrStaleObject, got %v", err)
+	}
+}
+
+// ========== Typed Query Tests ==========
+
+type UserInfo struct {
+	Base
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+	Note string
+}
+
+type Account struct {
+	Id   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+func (Account) TableName() string { return "t_account" }
+
+func TestTyped_AllDerivesTableAndColumns(t *testing.T) {
+	db := openFake(t)
+	fake.set([]string{"id", "name", "age"},
+		[]driver.Value{int64(1), "a", int64(18)},
+		[]driver.Value{int64(2), "b", int64(20)},
+	)
+	users, err := Typed[UserInfo](db).Where("age", ">", 10).All(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 2 || users[1].Id != 2 || users[1].Name != "b" {
+		t.Errorf("unexpected users: %+v", users)
+	}
+	expected := "select `user_info`.`id` as `id`,`user_info`.`name` as `name`,`user_info`.`age` as `age` from `user_info` as `user_info` where `user_info`.`age` > ?"
+	if fake.query != expected {
+		t.Errorf("expected %s, got %s", expected, fake.query)
+	}
+}
+
+func TestTyped_OneUsesTableNameAndLimit(t *testing.T) {
+	db := openFake(t)
+	fake.set([]string{"id", "name"}, []driver.Value{int64(7), "x"})
+	q := Typed[Account](db).Where("id", 7)
+	a, err := q.One(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.Id != 7 || a.Name != "x" {
+		t.Errorf("unexpected account: %+v", a)
+	}
+	expected := "select `t_account`.`id` as `id`,`t_account`.`name` as `name` from `t_account` as `t_account` where `t_account`.`id` = ? limit 1"
+	if fake.query != expected {
+		t.Errorf("expected %s, got %s", expected, fake.query)
+	}
+	// One 不应修改原查询
+	if sqlStr, _, _ := q.Builder().BuildSelect(); strings.Contains(sqlStr, "limit") {
+		t.Errorf("One should not modify the query: %s", sqlStr)
+	}
+
+	fake.set([]string{"id", "name"})
+	if _, err := q.One(context.Background()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestTyped_PageReturnsTotal(t *testing.T) {
+	db := openFake(t)
+	fake.set([]string{"id", "name"}, []driver.Value{int64(11), "k"})
+	fake.enqueue([]string{"_count"}, []driver.Value{int64(25)})
+	list, total, err := Typed[Account](db).Order([][]any{{"id", "desc"}}).Page(context.Background(), 2, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 25 || len(list) != 1 || list[0].Id != 11 {
+		t.Errorf("unexpected page: %d %+v", total, list)
+	}
+	expectedCount := "select count(*) as `_count` from (select `t_account`.`id` as `id`,`t_account`.`name` as `name` from `t_account` as `t_account`) as `_count`"
+	if fake.queries[0] != expectedCount {
+		t.Errorf("expected %s, got %s", expectedCount, fake.queries[0])
+	}
+	if !strings.HasSuffix(fake.queries[1], "order by `t_account`.`id` desc limit 10,10") {
+		t.Errorf("unexpected page query: %s", fake.queries[1])
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{"UserInfo": "user_info", "HTTPLog": "http_log", "User": "user", "OrderID": "order_id"}
+	for in, expected := range cases {
+		if got := snakeCase(in); got != expected {
+			t.Errorf("snakeCase(%s): expected %s, got %s", in, expected, got)
+		}
+	}
+}
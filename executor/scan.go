@@ -0,0 +1,239 @@
+package executor
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// fieldCacheKey 字段映射缓存的 key，同一类型在不同 tag 下映射不同
+type fieldCacheKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// fieldCache 缓存结构体类型的列名到字段索引路径的映射
+var fieldCache sync.Map
+
+// ScanOne 将 rows 的第一行扫描到 dest，没有结果时返回 sql.ErrNoRows
+// dest 为结构体指针或基础类型指针，tag 为空时使用 db
+func ScanOne(rows *sql.Rows, dest any, tag string) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("dest 必须是非 nil 指针")
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	s, err := newScanner(rows, v.Elem().Type(), tag)
+	if err != nil {
+		return err
+	}
+	return s.scan(rows, v.Elem())
+}
+
+// ScanAll 将 rows 的所有行扫描到 dest，tag 为空时使用 db
+// dest 为结构体切片指针、结构体指针切片指针或基础类型切片指针
+func ScanAll(rows *sql.Rows, dest any, tag string) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return errors.New("dest 必须是非 nil 的切片指针")
+	}
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	s, err := newScanner(rows, elemType, tag)
+	if err != nil {
+		return err
+	}
+	result := reflect.MakeSlice(slice.Type(), 0, 0)
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		if err := s.scan(rows, elem.Elem()); err != nil {
+			return err
+		}
+		if isPtr {
+			result = reflect.Append(result, elem)
+		} else {
+			result = reflect.Append(result, elem.Elem())
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	slice.Set(result)
+	return nil
+}
+
+// scanner 将结果集的列映射到目标类型
+type scanner struct {
+	columns []string
+	// 每一列对应的字段索引路径，目标为基础类型时为 nil
+	paths [][]int
+	// 每一列对应字段的类型，路径经过结构体指针时有值，否则为 nil
+	// 这类列先扫描到临时变量，全部为 NULL 时结构体指针保持 nil（如 LEFT JOIN 未匹配）
+	nilable []reflect.Type
+}
+
+// newScanner 按列名和 tag 建立列到字段的映射
+// 列名可以是 tag 名、嵌套结构体的 tag.子字段 tag（如 dept.name），
+// 或者带表别名的 alias.col（找不到完整路径时按 col 匹配）
+func newScanner(rows *sql.Rows, t reflect.Type, tag string) (*scanner, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	s := &scanner{columns: columns}
+	if isScalar(t) {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("扫描到 %s 需要恰好一列，实际 %d 列", t, len(columns))
+		}
+		return s, nil
+	}
+	fields := fieldsOf(t, tag)
+	s.paths = make([][]int, len(columns))
+	for i, col := range columns {
+		path, ok := fields[col]
+		if !ok {
+			if idx := strings.LastIndex(col, "."); idx >= 0 {
+				path, ok = fields[col[idx+1:]]
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("列 %s 在 %s 中没有对应的字段", col, t)
+		}
+		s.paths[i] = path
+		if ft, ok := throughPointer(t, path); ok {
+			if s.nilable == nil {
+				s.nilable = make([]reflect.Type, len(columns))
+			}
+			s.nilable[i] = ft
+		}
+	}
+	return s, nil
+}
+
+// throughPointer 判断索引路径是否经过结构体指针，是则返回路径末端字段的类型
+func throughPointer(t reflect.Type, path []int) (reflect.Type, bool) {
+	through := false
+	for i, idx := range path {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		t = t.Field(idx).Type
+		if i < len(path)-1 && t.Kind() == reflect.Ptr {
+			through = true
+		}
+	}
+	return t, through
+}
+
+// fieldsOf 返回结构体类型的列名到字段索引路径的映射，结果按类型和 tag 缓存
+func fieldsOf(t reflect.Type, tag string) map[string][]int {
+	if tag == "" {
+		tag = "db"
+	}
+	key := fieldCacheKey{t: t, tag: tag}
+	if v, ok := fieldCache.Load(key); ok {
+		return v.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	walkFields(t, tag, nil, "", fields)
+	v, _ := fieldCache.LoadOrStore(key, fields)
+	return v.(map[string][]int)
+}
+
+// walkFields 递归收集带 tag 的字段
+// 匿名嵌入的结构体（或结构体指针）字段视为外层字段，带 tag 的结构体字段以 tag. 为前缀展开
+func walkFields(t reflect.Type, tag string, prefix []int, namePrefix string, fields map[string][]int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		path := append(append([]int(nil), prefix...), i)
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		nested := ft.Kind() == reflect.Struct && !isScalar(ft)
+		if field.Anonymous && nested && name == "" {
+			walkFields(ft, tag, path, namePrefix, fields)
+			continue
+		}
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		if nested {
+			walkFields(ft, tag, path, namePrefix+name+".", fields)
+			continue
+		}
+		// 外层字段优先于嵌入结构体中的同名字段
+		if _, ok := fields[namePrefix+name]; !ok || len(path) < len(fields[namePrefix+name]) {
+			fields[namePrefix+name] = path
+		}
+	}
+}
+
+// scan 将当前行扫描到 v
+func (s *scanner) scan(rows *sql.Rows, v reflect.Value) error {
+	if s.paths == nil {
+		return rows.Scan(v.Addr().Interface())
+	}
+	dest := make([]any, len(s.columns))
+	for i, path := range s.paths {
+		if s.nilable != nil && s.nilable[i] != nil {
+			dest[i] = reflect.New(reflect.PointerTo(s.nilable[i])).Interface()
+			continue
+		}
+		dest[i] = fieldByIndex(v, path).Addr().Interface()
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+	for i, path := range s.paths {
+		if s.nilable == nil || s.nilable[i] == nil {
+			continue
+		}
+		// 非 NULL 时才分配路径上的结构体指针
+		if holder := reflect.ValueOf(dest[i]).Elem(); !holder.IsNil() {
+			fieldByIndex(v, path).Set(holder.Elem())
+		}
+	}
+	return nil
+}
+
+// fieldByIndex 按索引路径取字段，路径上的 nil 结构体指针会被分配
+func fieldByIndex(v reflect.Value, path []int) reflect.Value {
+	for i, idx := range path {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v
+}
+
+// isScalar 判断类型是否直接扫描（非结构体、time.Time 或实现了 sql.Scanner，如 sql.NullString）
+func isScalar(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	return t == timeType || reflect.PointerTo(t).Implements(scannerType)
+}
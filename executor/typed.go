@@ -0,0 +1,145 @@
+package executor
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/Ifkl/sqlbuilder"
+)
+
+// Tabler 自定义结构体对应的表名，未实现时使用类型名的蛇形命名（如 UserInfo -> user_info）
+type Tabler interface {
+	TableName() string
+}
+
+// TypedQuery 泛型查询，表名和查询字段由 T 的 db tag 推导，结果直接扫描为 T
+// 排序和条数在执行时应用到底层 builder 的副本上，Count 不受其影响
+type TypedQuery[T any] struct {
+	db    DB
+	b     *sqlbuilder.Builder
+	order [][]any
+	limit int64
+}
+
+// Typed 创建 T 的泛型查询，如 Typed[User](db).Where("status", 1).All(ctx)
+func Typed[T any](db DB) *TypedQuery[T] {
+	var zero T
+	b := sqlbuilder.From(tableNameOf(reflect.TypeOf(zero))).SelectStruct(&zero)
+	return &TypedQuery[T]{db: db, b: b}
+}
+
+// tableNameOf 返回结构体类型对应的表名
+func tableNameOf(t reflect.Type) string {
+	if v, ok := reflect.New(t).Interface().(Tabler); ok {
+		return v.TableName()
+	}
+	if v, ok := reflect.New(t).Elem().Interface().(Tabler); ok {
+		return v.TableName()
+	}
+	return snakeCase(t.Name())
+}
+
+// snakeCase 驼峰转蛇形：UserInfo -> user_info，HTTPLog -> http_log
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Builder 返回底层 builder，用于设置联表、分组、方言等其他条件
+func (q *TypedQuery[T]) Builder() *sqlbuilder.Builder {
+	return q.b
+}
+
+// Where 添加 AND 条件，参数格式与 WhereAnd 相同
+func (q *TypedQuery[T]) Where(args ...any) *TypedQuery[T] {
+	q.b.WhereAnd(args...)
+	return q
+}
+
+// WhereOr 添加 OR 条件，参数格式与 WhereOr 相同
+func (q *TypedQuery[T]) WhereOr(args ...any) *TypedQuery[T] {
+	q.b.WhereOr(args...)
+	return q
+}
+
+// Order 设置排序，参数格式与 Order 相同
+func (q *TypedQuery[T]) Order(order [][]any) *TypedQuery[T] {
+	q.order = order
+	return q
+}
+
+// Limit 限制返回条数
+func (q *TypedQuery[T]) Limit(n int64) *TypedQuery[T] {
+	q.limit = n
+	return q
+}
+
+// ordered 返回应用了排序的副本
+func (q *TypedQuery[T]) ordered() *sqlbuilder.Builder {
+	c := q.b.Clone()
+	if q.order != nil {
+		c.Order(q.order)
+	}
+	return c
+}
+
+// All 查询所有结果
+func (q *TypedQuery[T]) All(ctx context.Context) ([]T, error) {
+	c := q.ordered()
+	if q.limit != 0 {
+		c.Limit(q.limit)
+	}
+	var list []T
+	if err := Select(ctx, q.db, &list, c); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// One 查询第一条结果，没有结果时返回 sql.ErrNoRows
+func (q *TypedQuery[T]) One(ctx context.Context) (T, error) {
+	var one T
+	err := Get(ctx, q.db, &one, q.ordered().Limit(1))
+	return one, err
+}
+
+// Count 查询结果总数，忽略排序和条数
+func (q *TypedQuery[T]) Count(ctx context.Context) (int64, error) {
+	var total int64
+	err := Get(ctx, q.db, &total, countQuery{q.b})
+	return total, err
+}
+
+// Page 分页查询，返回第 p 页（从 1 开始）的 num 条结果和总数
+func (q *TypedQuery[T]) Page(ctx context.Context, p, num int64) ([]T, int64, error) {
+	total, err := q.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var list []T
+	if err := Select(ctx, q.db, &list, q.ordered().Page(p, num)); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// countQuery 将 BuildSelectCount 适配为 Selecter
+type countQuery struct {
+	b *sqlbuilder.Builder
+}
+
+func (c countQuery) BuildSelect() (string, []any, error) {
+	return c.b.BuildSelectCount()
+}
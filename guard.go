@@ -0,0 +1,264 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// guardRegistry 必需条件注册表：global 作用于所有表，tables 只作用于指定表
+var guardRegistry = struct {
+	sync.RWMutex
+	global []string
+	tables map[string][]string
+}{tables: make(map[string][]string)}
+
+// RequireCondition 要求查询、更新和删除时每个引用到的表都带有 column = 值 的等值条件，
+// 不指定 tables 时作用于所有表。构建时检查 WHERE 条件树（含注册的范围）、JOIN ON 条件，
+// 子查询在自身构建时同样检查；条件缺失、处于 or 分支或值为 NULL 时返回错误
+//
+//	sqlbuilder.RequireCondition("tenant_id", "order", "order_item")
+//
+// 联表可通过 ON 条件与已满足条件的表的同名列相等（或 USING 该列）来满足，
+// 原始 SQL 片段无法解析，不会被视为满足条件，以 or 追加的原始片段会使 WHERE 条件不再满足
+func RequireCondition(column string, tables ...string) {
+	guardRegistry.Lock()
+	defer guardRegistry.Unlock()
+	if len(tables) == 0 {
+		guardRegistry.global = appendUnique(guardRegistry.global, column)
+		return
+	}
+	for _, table := range tables {
+		guardRegistry.tables[table] = appendUnique(guardRegistry.tables[table], column)
+	}
+}
+
+// RemoveRequiredCondition 移除 RequireCondition 注册的必需条件，tables 与注册时对应
+func RemoveRequiredCondition(column string, tables ...string) {
+	guardRegistry.Lock()
+	defer guardRegistry.Unlock()
+	if len(tables) == 0 {
+		guardRegistry.global = removeString(guardRegistry.global, column)
+		return
+	}
+	for _, table := range tables {
+		guardRegistry.tables[table] = removeString(guardRegistry.tables[table], column)
+		if len(guardRegistry.tables[table]) == 0 {
+			delete(guardRegistry.tables, table)
+		}
+	}
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func removeString(list []string, s string) []string {
+	for i, v := range list {
+		if v == s {
+			return append(list[:i:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// requiredColumns 返回表必须带有等值条件的列
+func requiredColumns(table string) []string {
+	guardRegistry.RLock()
+	defer guardRegistry.RUnlock()
+	if len(guardRegistry.global) == 0 && len(guardRegistry.tables[table]) == 0 {
+		return nil
+	}
+	cols := append([]string(nil), guardRegistry.global...)
+	for _, col := range guardRegistry.tables[table] {
+		cols = appendUnique(cols, col)
+	}
+	return cols
+}
+
+// tableRef 语句中引用的表
+type tableRef struct {
+	table string
+	name  string // 条件中引用该表使用的名称：别名，无别名时为表名
+	join  *joinClause
+}
+
+// guardEnabled 是否注册了必需条件
+func guardEnabled() bool {
+	guardRegistry.RLock()
+	defer guardRegistry.RUnlock()
+	return len(guardRegistry.global) > 0 || len(guardRegistry.tables) > 0
+}
+
+// checkGuard 检查语句引用的每个表是否满足 RequireCondition 注册的必需条件
+// 子查询在自身构建时检查，错误由外层构建返回
+func (b *sqlBuilder) checkGuard() error {
+	if !guardEnabled() {
+		return nil
+	}
+	refs := make([]tableRef, 0, len(b.joins)+1)
+	if b.tableName != "" && b.fromQuery == nil {
+		refs = append(refs, tableRef{table: b.tableName, name: b.condTable()})
+	}
+	for i := range b.joins {
+		j := &b.joins[i]
+		if j.subquery != nil || j.tableName == "" {
+			continue
+		}
+		name := j.alias
+		if name == "" {
+			name = j.tableName
+		}
+		refs = append(refs, tableRef{table: j.tableName, name: name, join: j})
+	}
+	// guarded 记录已满足 列 条件的表名称，供后续联表的 ON/USING 传递
+	guarded := make(map[string]bool)
+	for _, ref := range refs {
+		for _, col := range requiredColumns(ref.table) {
+			if !b.whereGuards(ref.name, col) && !ref.joinGuards(b.condTable(), col, guarded) {
+				return fmt.Errorf("表 %s 缺少必需的条件: %s = ?", ref.name, col)
+			}
+			guarded[ref.name+"."+strings.ToLower(col)] = true
+		}
+	}
+	return nil
+}
+
+// whereGuards 判断 WHERE（含范围条件）是否保证 name.col = 值 成立
+// 用户条件与范围条件以 AND 连接，任一方满足即可
+func (b *sqlBuilder) whereGuards(name, col string) bool {
+	if b.scopeWhr != nil && guardsWhere(b.scopeWhr, name, col) {
+		return true
+	}
+	return andParts(b.customParts) && guardsWhere(b.whr, name, col)
+}
+
+// andParts 判断原始 SQL 片段是否都以 and 追加，以 or 追加的片段使之前的条件不再必然成立
+func andParts(parts []customPart) bool {
+	for _, cp := range parts {
+		if !strings.HasPrefix(cp.sql, "and ") {
+			return false
+		}
+	}
+	return true
+}
+
+// joinGuards 判断联表的 ON 条件或 USING 是否将已满足条件的表的同名列传递给该表
+func (ref tableRef) joinGuards(mainName, col string, guarded map[string]bool) bool {
+	if ref.join == nil {
+		return false
+	}
+	col = strings.ToLower(col)
+	for _, f := range ref.join.using {
+		if strings.EqualFold(f, col) {
+			for k := range guarded {
+				if strings.HasSuffix(k, "."+col) {
+					return true
+				}
+			}
+		}
+	}
+	for _, on := range ref.join.onConds {
+		if on.operator != "=" || !strings.EqualFold(on.leftField, col) || !strings.EqualFold(on.rightField, col) {
+			continue
+		}
+		left, right := on.leftTable, on.rightTable
+		if left == "" {
+			left = mainName
+		}
+		if right == "" {
+			right = mainName
+		}
+		if left == ref.name && guarded[right+"."+col] || right == ref.name && guarded[left+"."+col] {
+			return true
+		}
+	}
+	return false
+}
+
+// guardNode 条件树节点：单个条件或带括号的一组条件，rel 为节点前的连接关系
+type guardNode struct {
+	rel      string
+	cond     *Condition
+	children []guardNode
+}
+
+// guardsWhere 判断条件树是否保证 name.col = 值 成立
+// 节点结构与 parseWhere 的括号规则一致
+func guardsWhere(w *Where, name, col string) bool {
+	if w == nil {
+		return false
+	}
+	defTable := w.tableName
+	if w.alias != "" {
+		defTable = w.alias
+	}
+	var nodes []guardNode
+	for _, bigGroup := range w.assembleWhere {
+		var groups []guardNode
+		for _, v := range bigGroup {
+			var conds []guardNode
+			for k := range v.Condition {
+				c := &v.Condition[k]
+				rel := c.Relation
+				if rel == "" {
+					rel = v.Relation
+				}
+				conds = append(conds, guardNode{rel: rel, cond: c})
+			}
+			switch len(conds) {
+			case 0:
+			case 1:
+				groups = append(groups, conds[0])
+			default:
+				groups = append(groups, guardNode{rel: conds[0].rel, children: conds})
+			}
+		}
+		switch len(groups) {
+		case 0:
+		case 1:
+			nodes = append(nodes, groups[0])
+		default:
+			nodes = append(nodes, guardNode{rel: groups[0].rel, children: groups})
+		}
+	}
+	return guardsNodes(nodes, func(c *Condition) bool {
+		table := c.TableName
+		if table == "" {
+			table = defTable
+		}
+		return isGuardCond(c, table, name, col)
+	})
+}
+
+// guardsNodes AND 优先于 OR，以 or 切分出的每个 AND 链都必须包含满足条件的节点
+func guardsNodes(nodes []guardNode, match func(*Condition) bool) bool {
+	ok := false
+	for i, n := range nodes {
+		if i > 0 && strings.EqualFold(n.rel, "or") {
+			if !ok {
+				return false
+			}
+			ok = false
+		}
+		if n.cond != nil && match(n.cond) || n.cond == nil && guardsNodes(n.children, match) {
+			ok = true
+		}
+	}
+	return ok
+}
+
+// isGuardCond 判断条件是否为 name.col = 非 NULL 的绑定值
+func isGuardCond(c *Condition, table, name, col string) bool {
+	if c.FieldType != 1 || c.Condition != "=" || table != name || !strings.EqualFold(c.Field, col) {
+		return false
+	}
+	v, ok := bindValue(c.Value)
+	return ok && v != nil && !isNullValue(reflect.ValueOf(c.Value))
+}
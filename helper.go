@@ -1,6 +1,18 @@
 package sqlbuilder
 
-import "strings"
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
 
 // hasIllegalStr 检查字符串是否包含 SQL 注入危险字符
 func hasIllegalStr(val string) bool {
@@ -39,3 +51,54 @@ func isSafeIdentifierAny(vals ...string) bool {
 	}
 	return true
 }
+
+// bindValue 将值规范为可直接绑定的参数，不支持的类型返回 false
+// 非 nil 指针解引用，nil 指针为 NULL；driver.Valuer（如 sql.NullString）、time.Time、[]byte
+// 以及底层类型为 bool、字符串、数值的类型原样保留
+func bindValue(v any) (any, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, true
+	}
+	switch v.(type) {
+	case nil, driver.Valuer, time.Time, []byte:
+		return v, true
+	}
+	switch rv.Kind() {
+	case reflect.Ptr:
+		return bindValue(rv.Elem().Interface())
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return v, true
+	}
+	return nil, false
+}
+
+// fieldInterface 返回结构体字段的值，指针接收者实现 driver.Valuer 的字段取其地址
+func fieldInterface(v reflect.Value) any {
+	if v.Kind() != reflect.Ptr && !v.Type().Implements(valuerType) && v.CanAddr() && v.Addr().Type().Implements(valuerType) {
+		return v.Addr().Interface()
+	}
+	return v.Interface()
+}
+
+// isNullValue 判断字段值是否为 NULL：nil 指针、nil []byte 或 Value() 返回 nil 的 driver.Valuer（如无效的 sql.NullInt64）
+func isNullValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return true
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.IsNil() {
+			return true
+		}
+	}
+	if valuer, ok := fieldInterface(v).(driver.Valuer); ok {
+		val, err := valuer.Value()
+		return err == nil && val == nil
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package sqlbuilder
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BuildEvent 一次 Build* 调用的信息
+type BuildEvent struct {
+	// 构建方法名，如 BuildSelect、BuildMapUpdate
+	Op string
+	// 主表名
+	Table string
+	// 构建结果，AfterBuild 时可用；批量构建时为以 ; 连接的多条语句
+	SQL string
+	// 参数值，命名参数插入时为空
+	Args []any
+	// 构建开始时间及耗时
+	Start    time.Time
+	Duration time.Duration
+	// 构建错误
+	Err error
+}
+
+// Hook 构建钩子，每个 Build* 方法在构建前调用 BeforeBuild，构建后调用 AfterBuild
+// 同一次构建的两次调用传入同一个 BuildEvent，钩子需自行保证并发安全
+type Hook interface {
+	BeforeBuild(e *BuildEvent)
+	AfterBuild(e *BuildEvent)
+}
+
+// Logger 日志接口，*log.Logger 满足该接口
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// globalHooks 作用于所有 builder 的钩子，先于 builder 自身的钩子调用
+var globalHooks = struct {
+	sync.RWMutex
+	list []Hook
+}{}
+
+// debugLogger Debug() 使用的日志输出，默认输出到标准输出
+var debugLogger Logger = log.New(os.Stdout, "", 0)
+
+// AddHook 添加全局钩子
+func AddHook(h Hook) {
+	globalHooks.Lock()
+	defer globalHooks.Unlock()
+	globalHooks.list = append(globalHooks.list, h)
+}
+
+// ClearHooks 移除所有全局钩子
+func ClearHooks() {
+	globalHooks.Lock()
+	defer globalHooks.Unlock()
+	globalHooks.list = nil
+}
+
+// SetDebugLogger 设置 Debug() 的日志输出
+func SetDebugLogger(l Logger) {
+	debugLogger = l
+}
+
+// Hook 为当前 builder 添加钩子，在全局钩子之后调用
+func (b *sqlBuilder) Hook(hooks ...Hook) *sqlBuilder {
+	b.hooks = append(b.hooks, hooks...)
+	return b
+}
+
+// LogHook 返回将每次构建的 SQL、参数、耗时和错误输出到 l 的钩子
+func LogHook(l Logger) Hook {
+	return logHook{l: l}
+}
+
+type logHook struct {
+	l Logger
+}
+
+func (h logHook) BeforeBuild(e *BuildEvent) {}
+
+func (h logHook) AfterBuild(e *BuildEvent) {
+	if e.Err != nil {
+		h.l.Printf("[sqlbuilder] %s %s error: %v", e.Op, e.Duration, e.Err)
+		return
+	}
+	h.l.Printf("[sqlbuilder] %s %s | args: %v | %s", e.Op, e.SQL, e.Args, e.Duration)
+}
+
+// observe 在构建前调用钩子的 BeforeBuild，返回构建后调用 AfterBuild 的函数，配合命名返回值使用：
+//
+//	defer b.observe("BuildSelect")(&sqlStr, &args, &err)
+//
+// 没有钩子或处于外层 Build* 内部（nested）时返回空函数
+func (b *sqlBuilder) observe(op string) func(sqlStr *string, args *[]any, err *error) {
+	if b.nested {
+		return func(*string, *[]any, *error) {}
+	}
+	globalHooks.RLock()
+	hooks := append([]Hook(nil), globalHooks.list...)
+	globalHooks.RUnlock()
+	hooks = append(hooks, b.hooks...)
+	if b.debugSql {
+		hooks = append(hooks, LogHook(debugLogger))
+	}
+	if len(hooks) == 0 {
+		return func(*string, *[]any, *error) {}
+	}
+	e := &BuildEvent{Op: op, Table: b.tableName, Start: time.Now()}
+	for _, h := range hooks {
+		h.BeforeBuild(e)
+	}
+	return func(sqlStr *string, args *[]any, err *error) {
+		e.Duration = time.Since(e.Start)
+		if sqlStr != nil {
+			e.SQL = *sqlStr
+		}
+		if args != nil {
+			e.Args = *args
+		}
+		if err != nil {
+			e.Err = *err
+		}
+		for _, h := range hooks {
+			h.AfterBuild(e)
+		}
+	}
+}
+
+// observeBatch 适配批量构建，多条语句以 ; 连接，参数按语句顺序拼接
+func (b *sqlBuilder) observeBatch(op string) func(stmts *[]Statement, err *error) {
+	done := b.observe(op)
+	return func(stmts *[]Statement, err *error) {
+		var sqls []string
+		var args []any
+		for _, s := range *stmts {
+			sqls = append(sqls, s.SQL)
+			args = append(args, s.Args...)
+		}
+		sqlStr := strings.Join(sqls, "; ")
+		done(&sqlStr, &args, err)
+	}
+}
+
+// silent 返回不触发钩子的浅拷贝，用于 Build* 内部调用其他 Build* 方法
+func (b *sqlBuilder) silent() *sqlBuilder {
+	c := *b
+	c.nested = true
+	return &c
+}
@@ -1,7 +1,9 @@
 package sqlbuilder
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -15,8 +17,42 @@ func validateMapKeys(option map[string]any) error {
 	return nil
 }
 
+// quoteAll 按方言引用多个标识符
+func (b *sqlBuilder) quoteAll(idents []string) []string {
+	res := make([]string, len(idents))
+	for i, ident := range idents {
+		res[i] = b.quote(ident)
+	}
+	return res
+}
+
+// orderedKeys 返回 map 的列名，按 ColumnOrder 指定的顺序输出，其余列按字母序排在后面
+// 保证同样的输入总是生成同样的 SQL
+func (b *sqlBuilder) orderedKeys(option map[string]any) []string {
+	keys := make([]string, 0, len(option))
+	seen := make(map[string]bool, len(b.columnOrder))
+	for _, k := range b.columnOrder {
+		if _, ok := option[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	rest := len(keys)
+	for k := range option {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[rest:])
+	return keys
+}
+
 // buildMapInsert 内部 insert 辅助方法，prefix 支持 "insert", "insert ignore", "replace"
 func (b *sqlBuilder) buildMapInsert(prefix string, option map[string]any) (string, []any) {
+	if err := b.checkErr(); err != nil {
+		b.err = err
+		return "", nil
+	}
 	if len(option) == 0 {
 		return "", nil
 	}
@@ -24,98 +60,271 @@ func (b *sqlBuilder) buildMapInsert(prefix string, option map[string]any) (strin
 		b.err = err
 		return "", nil
 	}
+	if err := b.checkInsertPrefix(prefix); err != nil {
+		b.err = err
+		return "", nil
+	}
+	keys := b.orderedKeys(option)
 	keysArr := []string{}
 	valsArr := []any{}
 	placeArr := []string{}
-	for k, v := range option {
-		keysArr = append(keysArr, fmt.Sprintf("`%s`", k))
-		valsArr = append(valsArr, v)
+	for _, k := range keys {
+		keysArr = append(keysArr, b.quote(k))
+		valsArr = append(valsArr, option[k])
 		placeArr = append(placeArr, "?")
 	}
-	sqlStr := fmt.Sprintf("%s into `%s` (%s) values (%s)", prefix, b.tableName, strings.Join(keysArr, ","), strings.Join(placeArr, ","))
+	sqlStr := fmt.Sprintf("%s into %s (%s) values (%s)", prefix, b.quote(b.tableName), strings.Join(keysArr, ","), strings.Join(placeArr, ","))
+
+	// ON DUPLICATE KEY UPDATE / ON CONFLICT
+	suffix, suffixArgs, err := b.insertSuffix(keys)
+	if err != nil {
+		b.err = err
+		return "", nil
+	}
+	sqlStr += suffix
+	valsArr = append(valsArr, suffixArgs...)
+
+	return rebind(b.getDialect(), sqlStr), valsArr
+}
+
+// renderOnDuplicateKey 渲染 [AS alias] ON DUPLICATE KEY UPDATE 子句，带前导空格
+// 显式设置的列按 orderedKeys 顺序在前，OnDuplicateKeyUpdateAll 补充的列按插入顺序在后
+func (b *sqlBuilder) renderOnDuplicateKey(columns []string) (string, []any, error) {
+	if err := b.require(FeatureOnDuplicateKey, "on duplicate key update"); err != nil {
+		return "", nil, err
+	}
+	var args []any
+	var dupParts []string
+	for _, k := range b.orderedKeys(b.onDuplicateUpdates) {
+		expr, exprArgs, err := b.renderSetValue(b.onDuplicateUpdates[k], "")
+		if err != nil {
+			return "", nil, fmt.Errorf("ON DUPLICATE KEY UPDATE 字段 %s: %w", k, err)
+		}
+		dupParts = append(dupParts, fmt.Sprintf("%s = %s", b.quote(k), expr))
+		args = append(args, exprArgs...)
+	}
+	if b.onDuplicateAll {
+		skip := make(map[string]bool, len(b.onDuplicateExcept))
+		for _, k := range b.onDuplicateExcept {
+			skip[k] = true
+		}
+		for _, k := range columns {
+			if _, ok := b.onDuplicateUpdates[k]; ok || skip[k] {
+				continue
+			}
+			if b.rowAlias != "" {
+				dupParts = append(dupParts, fmt.Sprintf("%s = %s", b.quote(k), b.quoteCol(b.rowAlias, k)))
+			} else {
+				dupParts = append(dupParts, fmt.Sprintf("%s = values(%s)", b.quote(k), b.quote(k)))
+			}
+		}
+	}
+	if len(dupParts) == 0 {
+		return "", nil, errors.New("ON DUPLICATE KEY UPDATE 没有可更新的字段")
+	}
+	var sb strings.Builder
+	if b.rowAlias != "" {
+		sb.WriteString(" as " + b.quote(b.rowAlias))
+	}
+	sb.WriteString(" on duplicate key update " + strings.Join(dupParts, ", "))
+	return sb.String(), args, nil
+}
+
+// checkInsertPrefix 校验 INSERT 前缀在当前方言下是否可用
+func (b *sqlBuilder) checkInsertPrefix(prefix string) error {
+	switch prefix {
+	case "insert ignore":
+		return b.require(FeatureInsertIgnore, prefix)
+	case "replace":
+		return b.require(FeatureReplace, prefix)
+	}
+	return nil
+}
+
+// 批量 map 插入时各行列集合不一致的处理方式
+const (
+	// rowKeysFirst 以第一行的列为准，其他行缺失的列插入 NULL，多出的列忽略（严格模式下报错）
+	rowKeysFirst = iota
+	// rowKeysExact 所有行的列集合必须一致，否则报错
+	rowKeysExact
+	// rowKeysUnion 取所有行列的并集，缺失的列使用 rowDefaults 中的默认值
+	rowKeysUnion
+)
+
+// ExactRowKeys 批量 map 插入时要求所有行的列集合一致，否则构建报错
+func (b *sqlBuilder) ExactRowKeys() *sqlBuilder {
+	b.rowKeysMode = rowKeysExact
+	b.rowDefaults = nil
+	return b
+}
+
+// UnionRowKeys 批量 map 插入时取所有行列的并集，某行缺失的列使用 defaults 中的默认值
+// 缺失的列在 defaults 中也没有时构建报错；默认值为 nil 表示插入 NULL
+func (b *sqlBuilder) UnionRowKeys(defaults map[string]any) *sqlBuilder {
+	if err := validateMapKeys(defaults); err != nil {
+		b.err = err
+		return b
+	}
+	b.rowKeysMode = rowKeysUnion
+	b.rowDefaults = defaults
+	return b
+}
+
+// rowKeys 校验每一行的列名，并按 rowKeysMode 确定批量插入的列
+func (b *sqlBuilder) rowKeys(option []map[string]any) ([]string, error) {
+	for _, row := range option {
+		if err := validateMapKeys(row); err != nil {
+			return nil, err
+		}
+	}
+	first := option[0]
+	switch b.rowKeysMode {
+	case rowKeysUnion:
+		all := make(map[string]any)
+		for _, row := range option {
+			for k := range row {
+				all[k] = nil
+			}
+		}
+		keys := b.orderedKeys(all)
+		for i, row := range option {
+			for _, k := range keys {
+				if _, ok := row[k]; ok {
+					continue
+				}
+				if _, ok := b.rowDefaults[k]; !ok {
+					return nil, fmt.Errorf("第 %d 行缺少列 %s 且没有默认值", i+1, k)
+				}
+			}
+		}
+		return keys, nil
+	default:
+		for i, row := range option[1:] {
+			if err := sameKeys(first, row); err != nil {
+				if b.rowKeysMode == rowKeysExact {
+					return nil, fmt.Errorf("第 %d 行%w", i+2, err)
+				}
+				if b.isStrict() {
+					return nil, fmt.Errorf("严格模式: 第 %d 行%w", i+2, err)
+				}
+			}
+		}
+		return b.orderedKeys(first), nil
+	}
+}
 
-	// ON DUPLICATE KEY UPDATE
-	if len(b.onDuplicateUpdates) > 0 {
-		var dupParts []string
-		for k, v := range b.onDuplicateUpdates {
-			dupParts = append(dupParts, fmt.Sprintf("`%s` = ?", k))
-			valsArr = append(valsArr, v)
+// sameKeys 比较两行的列集合
+func sameKeys(first, row map[string]any) error {
+	for k := range first {
+		if _, ok := row[k]; !ok {
+			return fmt.Errorf("缺少列 %s", k)
 		}
-		sqlStr = fmt.Sprintf("%s on duplicate key update %s", sqlStr, strings.Join(dupParts, ", "))
 	}
+	for k := range row {
+		if _, ok := first[k]; !ok {
+			return fmt.Errorf("多出列 %s", k)
+		}
+	}
+	return nil
+}
 
-	return sqlStr, valsArr
+// rowValue 取行中某列的值，缺失时使用 UnionRowKeys 设置的默认值
+func (b *sqlBuilder) rowValue(row map[string]any, k string) any {
+	if v, ok := row[k]; ok {
+		return v
+	}
+	return b.rowDefaults[k]
 }
 
 // buildSliceMapInsert 内部批量 insert 辅助方法
 func (b *sqlBuilder) buildSliceMapInsert(prefix string, option []map[string]any) (string, []any) {
+	if err := b.checkErr(); err != nil {
+		b.err = err
+		return "", nil
+	}
 	if len(option) == 0 {
 		return "", nil
 	}
-	if err := validateMapKeys(option[0]); err != nil {
+	keys, err := b.rowKeys(option)
+	if err != nil {
 		b.err = err
 		return "", nil
 	}
-	first := option[0]
-	keys := make([]string, 0, len(first))
-	for k := range first {
-		keys = append(keys, k)
+	if err := b.checkInsertPrefix(prefix); err != nil {
+		b.err = err
+		return "", nil
 	}
+	sqlStr, fieldValue, err := b.renderSliceMapInsert(prefix, keys, option)
+	if err != nil {
+		b.err = err
+		return "", nil
+	}
+	return sqlStr, fieldValue
+}
 
+// renderSliceMapInsert 按给定的列渲染批量 insert，缺失的列按 rowValue 取默认值
+func (b *sqlBuilder) renderSliceMapInsert(prefix string, keys []string, option []map[string]any) (string, []any, error) {
 	var (
 		fieldValue  []any
 		sqlValueArr []string
 	)
 	keysArr := make([]string, len(keys))
 	for i, k := range keys {
-		keysArr[i] = fmt.Sprintf("`%s`", k)
+		keysArr[i] = b.quote(k)
 	}
 
 	for _, row := range option {
 		placeholders := make([]string, len(keys))
 		for i, k := range keys {
-			fieldValue = append(fieldValue, row[k])
+			fieldValue = append(fieldValue, b.rowValue(row, k))
 			placeholders[i] = "?"
 		}
 		sqlValueArr = append(sqlValueArr, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
 	}
-	insertSql := fmt.Sprintf("%s into `%s` (%s) values %s", prefix, b.tableName, strings.Join(keysArr, ","), strings.Join(sqlValueArr, ","))
+	insertSql := fmt.Sprintf("%s into %s (%s) values %s", prefix, b.quote(b.tableName), strings.Join(keysArr, ","), strings.Join(sqlValueArr, ","))
 
-	// ON DUPLICATE KEY UPDATE
-	if len(b.onDuplicateUpdates) > 0 {
-		var dupParts []string
-		for k, v := range b.onDuplicateUpdates {
-			dupParts = append(dupParts, fmt.Sprintf("`%s` = ?", k))
-			fieldValue = append(fieldValue, v)
-		}
-		insertSql = fmt.Sprintf("%s on duplicate key update %s", insertSql, strings.Join(dupParts, ", "))
+	// ON DUPLICATE KEY UPDATE / ON CONFLICT
+	suffix, suffixArgs, err := b.insertSuffix(keys)
+	if err != nil {
+		return "", nil, err
 	}
+	insertSql += suffix
+	fieldValue = append(fieldValue, suffixArgs...)
 
-	return insertSql, fieldValue
+	return rebind(b.getDialect(), insertSql), fieldValue, nil
 }
 
 // BuildMapInsertIgnore 使用 map 构建 INSERT IGNORE SQL
-func (b *sqlBuilder) BuildMapInsertIgnore(option map[string]any) (string, []any) {
+func (b *sqlBuilder) BuildMapInsertIgnore(option map[string]any) (sqlStr string, args []any) {
+	defer b.observe("BuildMapInsertIgnore")(&sqlStr, &args, &b.err)
 	return b.buildMapInsert("insert ignore", option)
 }
 
 // BuildMapReplace 使用 map 构建 REPLACE INTO SQL
-func (b *sqlBuilder) BuildMapReplace(option map[string]any) (string, []any) {
+func (b *sqlBuilder) BuildMapReplace(option map[string]any) (sqlStr string, args []any) {
+	defer b.observe("BuildMapReplace")(&sqlStr, &args, &b.err)
 	return b.buildMapInsert("replace", option)
 }
 
 // BuildSliceMapInsertIgnore 使用 map 切片构建批量 INSERT IGNORE SQL
-func (b *sqlBuilder) BuildSliceMapInsertIgnore(option []map[string]any) (string, []any) {
+func (b *sqlBuilder) BuildSliceMapInsertIgnore(option []map[string]any) (sqlStr string, args []any) {
+	defer b.observe("BuildSliceMapInsertIgnore")(&sqlStr, &args, &b.err)
 	return b.buildSliceMapInsert("insert ignore", option)
 }
 
 // BuildSliceMapReplace 使用 map 切片构建批量 REPLACE INTO SQL
-func (b *sqlBuilder) BuildSliceMapReplace(option []map[string]any) (string, []any) {
+func (b *sqlBuilder) BuildSliceMapReplace(option []map[string]any) (sqlStr string, args []any) {
+	defer b.observe("BuildSliceMapReplace")(&sqlStr, &args, &b.err)
 	return b.buildSliceMapInsert("replace", option)
 }
 
 // BuildInsertSet 使用 map 构建 INSERT ... SET SQL（MySQL）
-func (b *sqlBuilder) BuildInsertSet(option map[string]any) (string, []any) {
+func (b *sqlBuilder) BuildInsertSet(option map[string]any) (sqlStr string, args []any) {
+	defer b.observe("BuildInsertSet")(&sqlStr, &args, &b.err)
+	if err := b.checkErr(); err != nil {
+		b.err = err
+		return "", nil
+	}
 	if len(option) == 0 {
 		return "", nil
 	}
@@ -123,31 +332,39 @@ func (b *sqlBuilder) BuildInsertSet(option map[string]any) (string, []any) {
 		b.err = err
 		return "", nil
 	}
+	if err := b.require(FeatureInsertSet, "insert ... set"); err != nil {
+		b.err = err
+		return "", nil
+	}
 	var setParts []string
 	var vals []any
-	for k, v := range option {
-		setParts = append(setParts, fmt.Sprintf("`%s` = ?", k))
-		vals = append(vals, v)
+	for _, k := range b.orderedKeys(option) {
+		setParts = append(setParts, fmt.Sprintf("%s = ?", b.quote(k)))
+		vals = append(vals, option[k])
 	}
-	return fmt.Sprintf("insert into `%s` set %s", b.tableName, strings.Join(setParts, ", ")), vals
+	return fmt.Sprintf("insert into %s set %s", b.quote(b.tableName), strings.Join(setParts, ", ")), vals
 }
 
 // BuildInsertSelect 构建 INSERT ... SELECT SQL
-func (b *sqlBuilder) BuildInsertSelect(columns []string, selectBuilder *sqlBuilder) (string, []any, error) {
+func (b *sqlBuilder) BuildInsertSelect(columns []string, selectBuilder *sqlBuilder) (sqlStr string, args []any, err error) {
+	defer b.observe("BuildInsertSelect")(&sqlStr, &args, &err)
+	if err := b.checkErr(); err != nil {
+		return "", nil, err
+	}
 	if selectBuilder == nil {
 		return "", nil, fmt.Errorf("INSERT SELECT 子查询不能为 nil")
 	}
 	if !isSafeIdentifierAny(columns...) {
 		return "", nil, fmt.Errorf("非法的 INSERT SELECT 列名")
 	}
-	selectSql, selectArgs, err := selectBuilder.BuildSelect()
+	selectSql, selectArgs, err := selectBuilder.subquery(b.getDialect())
 	if err != nil {
 		return "", nil, err
 	}
 	cols := make([]string, len(columns))
 	for i, col := range columns {
-		cols[i] = fmt.Sprintf("`%s`", col)
+		cols[i] = b.quote(col)
 	}
-	sql := fmt.Sprintf("insert into `%s` (%s) %s", b.tableName, strings.Join(cols, ", "), selectSql)
-	return sql, selectArgs, nil
+	sql := fmt.Sprintf("insert into %s (%s) %s", b.quote(b.tableName), strings.Join(cols, ", "), selectSql)
+	return rebind(b.getDialect(), sql), selectArgs, nil
 }
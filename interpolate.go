@@ -0,0 +1,231 @@
+package sqlbuilder
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interpolate 将参数按方言的字面量语法内联到 SQL 的占位符中，用于日志和调试时复制到数据库客户端执行
+// 仅供阅读，不可用于执行：内联结果绕过了参数绑定，与驱动的实际转义（字符集、sql_mode 等）可能不一致
+// 支持字符串、[]byte、time.Time、nil、bool、数值及其指针和 driver.Valuer；引号内的 ? 不视为占位符
+func Interpolate(d Dialect, query string, args []any) (string, error) {
+	if d == nil {
+		d = defaultDialect
+	}
+	// 方言占位符为 ? 时按出现顺序对应参数，否则解析 $1、@p1 这类带序号的占位符
+	prefix := strings.TrimSuffix(d.Placeholder(1), "1")
+	var sb strings.Builder
+	sb.Grow(len(query) + len(args)*8)
+	n := 0
+	used := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case prefix == "?" && c == '?':
+			n++
+			if n > len(args) {
+				return "", fmt.Errorf("占位符数量多于参数数量 %d", len(args))
+			}
+			lit, err := literal(d, args[n-1])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(lit)
+			used = n
+			continue
+		case prefix != "?" && strings.HasPrefix(query[i:], prefix):
+			j := i + len(prefix)
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if j == i+len(prefix) {
+				break
+			}
+			idx, _ := strconv.Atoi(query[i+len(prefix) : j])
+			if idx < 1 || idx > len(args) {
+				return "", fmt.Errorf("占位符 %s 超出参数数量 %d", query[i:j], len(args))
+			}
+			lit, err := literal(d, args[idx-1])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(lit)
+			if idx > used {
+				used = idx
+			}
+			i = j - 1
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	if used != len(args) {
+		return "", fmt.Errorf("参数数量 %d 与占位符数量 %d 不一致", len(args), used)
+	}
+	return sb.String(), nil
+}
+
+// Interpolate 使用当前方言内联 Build* 的结果，可直接传入 Build* 的返回值：
+//
+//	sqlStr, err := b.Interpolate(b.BuildMapUpdate(m))
+//
+// 仅供日志和调试，不可用于执行；errs 中的错误原样返回
+func (b *sqlBuilder) Interpolate(query string, args []any, errs ...error) (string, error) {
+	for _, err := range errs {
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := b.checkErr(); err != nil {
+		return "", err
+	}
+	return Interpolate(b.getDialect(), query, args)
+}
+
+// BuildSelectInterpolated 构建参数已内联的 SELECT SQL，仅供日志和调试，不可用于执行
+func (b *sqlBuilder) BuildSelectInterpolated() (string, error) {
+	return b.Interpolate(b.BuildSelect())
+}
+
+// String 返回参数已内联的 SELECT SQL，仅供日志和调试，不可用于执行；构建失败时返回 SQL 注释形式的错误
+func (b *sqlBuilder) String() string {
+	if b == nil {
+		return "<nil>"
+	}
+	c := b.silent()
+	sqlStr, err := c.Interpolate(c.BuildSelect())
+	if err != nil {
+		return fmt.Sprintf("/* sqlbuilder: %v */", err)
+	}
+	return sqlStr
+}
+
+// literal 将参数渲染为方言的字面量
+func literal(d Dialect, v any) (string, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return "NULL", nil
+		}
+		val, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		if _, ok := val.(driver.Valuer); ok {
+			return "", fmt.Errorf("driver.Valuer %T 返回了 driver.Valuer", v)
+		}
+		return literal(d, val)
+	}
+	switch val := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return quoteString(d, val), nil
+	case []byte:
+		if val == nil {
+			return "NULL", nil
+		}
+		return quoteBytes(d, val), nil
+	case time.Time:
+		return quoteTime(d, val), nil
+	case bool:
+		if d.Name() == "postgres" {
+			return strings.ToUpper(strconv.FormatBool(val)), nil
+		}
+		if val {
+			return "1", nil
+		}
+		return "0", nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return literal(d, rv.Elem().Interface())
+	case reflect.String:
+		return quoteString(d, rv.String()), nil
+	case reflect.Bool:
+		return literal(d, rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return literal(d, rv.Bytes())
+		}
+	}
+	return "", fmt.Errorf("无法内联的参数类型: %T", v)
+}
+
+// quoteString 渲染字符串字面量
+// MySQL/MariaDB 默认启用反斜杠转义，其他方言按标准 SQL 将单引号写两次，SQL Server 加 N 前缀保留 Unicode
+func quoteString(d Dialect, s string) string {
+	switch d.(type) {
+	case *mysqlDialect, *mariaDBDialect:
+		var sb strings.Builder
+		sb.Grow(len(s) + 2)
+		sb.WriteByte('\'')
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; c {
+			case 0:
+				sb.WriteString(`\0`)
+			case '\n':
+				sb.WriteString(`\n`)
+			case '\r':
+				sb.WriteString(`\r`)
+			case 0x1a:
+				sb.WriteString(`\Z`)
+			case '\'', '\\':
+				sb.WriteByte('\\')
+				sb.WriteByte(c)
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		sb.WriteByte('\'')
+		return sb.String()
+	}
+	if d.Name() == "sqlserver" {
+		return "N'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteBytes 渲染二进制字面量
+func quoteBytes(d Dialect, b []byte) string {
+	switch d.Name() {
+	case "postgres":
+		return `'\x` + hex.EncodeToString(b) + "'"
+	case "sqlserver":
+		return "0x" + hex.EncodeToString(b)
+	}
+	return "X'" + hex.EncodeToString(b) + "'"
+}
+
+// quoteTime 渲染时间字面量，PostgreSQL 带时区偏移，其他方言按时间自身的时区输出本地时间
+func quoteTime(d Dialect, t time.Time) string {
+	if d.Name() == "postgres" {
+		return "'" + t.Format("2006-01-02 15:04:05.999999-07:00") + "'"
+	}
+	return "'" + t.Format("2006-01-02 15:04:05.999999") + "'"
+}
@@ -31,7 +31,7 @@ func (j joinType) keyword() string {
 	case straightJoin:
 		return "straight_join"
 	case fullOuterJoin:
-		return "left join" // FULL OUTER JOIN emulated as LEFT JOIN for MySQL
+		return "left join" // 方言不支持 FULL OUTER JOIN 时用 LEFT JOIN 模拟
 	case innerJoin:
 		return "join"
 	default:
@@ -62,12 +62,21 @@ type joinClause struct {
 func (b *sqlBuilder) renderJoin(j joinClause) string {
 	var sb strings.Builder
 
-	sb.WriteString(j.typ.keyword())
+	d := b.getDialect()
+	switch {
+	case j.typ == straightJoin && !d.Supports(FeatureStraightJoin):
+		b.err = fmt.Errorf("%s 方言不支持 straight_join", d.Name())
+		return ""
+	case j.typ == fullOuterJoin && d.Supports(FeatureFullJoin):
+		sb.WriteString("full outer join")
+	default:
+		sb.WriteString(j.typ.keyword())
+	}
 	sb.WriteByte(' ')
 
 	// 表或子查询
 	if j.subquery != nil {
-		q, args, err := j.subquery.BuildSelect()
+		q, args, err := j.subquery.subquery(d)
 		if err != nil {
 			b.err = err
 			return ""
@@ -75,19 +84,19 @@ func (b *sqlBuilder) renderJoin(j joinClause) string {
 		b.fieldValue = append(b.fieldValue, args...)
 		sb.WriteString(fmt.Sprintf("(%s)", q))
 	} else {
-		sb.WriteString(fmt.Sprintf("`%s`", j.tableName))
+		sb.WriteString(d.Quote(j.tableName))
 	}
 
 	// 别名
 	if j.alias != "" {
-		sb.WriteString(fmt.Sprintf(" as `%s`", j.alias))
+		sb.WriteString(" as " + d.Quote(j.alias))
 	}
 
 	// USING
 	if len(j.using) > 0 {
 		quoted := make([]string, len(j.using))
 		for i, f := range j.using {
-			quoted[i] = fmt.Sprintf("`%s`", f)
+			quoted[i] = d.Quote(f)
 		}
 		sb.WriteString(fmt.Sprintf(" using (%s)", strings.Join(quoted, ", ")))
 		return sb.String()
@@ -100,8 +109,8 @@ func (b *sqlBuilder) renderJoin(j joinClause) string {
 			if i > 0 {
 				sb.WriteString(" and ")
 			}
-			sb.WriteString(fmt.Sprintf("`%s`.`%s` %s `%s`.`%s`",
-				c.leftTable, c.leftField, c.operator, c.rightTable, c.rightField))
+			sb.WriteString(fmt.Sprintf("%s %s %s",
+				b.quoteCol(c.leftTable, c.leftField), c.operator, b.quoteCol(c.rightTable, c.rightField)))
 		}
 	}
 
@@ -231,7 +240,7 @@ func (b *sqlBuilder) StraightJoin(tableName, alias string) *sqlBuilder {
 	return b
 }
 
-// FullJoin 全外连接（MySQL 用 LEFT JOIN 模拟）
+// FullJoin 全外连接（方言不支持时用 LEFT JOIN 模拟，如 MySQL）
 func (b *sqlBuilder) FullJoin(tableName, alias, f1, f2 string) *sqlBuilder {
 	if !isSafeIdentifierAny(tableName, alias, f1, f2) {
 		b.err = fmt.Errorf("非法的 FULL JOIN 参数")
@@ -264,6 +273,7 @@ func (b *sqlBuilder) JoinOn(tableName, alias string, ons ...[]string) *sqlBuilde
 	jc := joinClause{typ: innerJoin, tableName: tableName, alias: alias}
 	for _, on := range ons {
 		if len(on) < 5 {
+			b.skip(fmt.Errorf("JOIN ON 条件格式错误，已被忽略: %v", on))
 			continue
 		}
 		if !isSafeIdentifierAny(on...) {
@@ -291,6 +301,7 @@ func (b *sqlBuilder) LeftJoinOn(tableName, alias string, ons ...[]string) *sqlBu
 	jc := joinClause{typ: leftJoin, tableName: tableName, alias: alias}
 	for _, on := range ons {
 		if len(on) < 5 {
+			b.skip(fmt.Errorf("JOIN ON 条件格式错误，已被忽略: %v", on))
 			continue
 		}
 		if !isSafeIdentifierAny(on...) {
@@ -318,6 +329,7 @@ func (b *sqlBuilder) RightJoinOn(tableName, alias string, ons ...[]string) *sqlB
 	jc := joinClause{typ: rightJoin, tableName: tableName, alias: alias}
 	for _, on := range ons {
 		if len(on) < 5 {
+			b.skip(fmt.Errorf("JOIN ON 条件格式错误，已被忽略: %v", on))
 			continue
 		}
 		if !isSafeIdentifierAny(on...) {
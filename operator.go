@@ -2,6 +2,7 @@ package sqlbuilder
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 )
@@ -54,16 +55,33 @@ func init() {
 	}
 }
 
+// knownOperator 运算符是否已注册，匹配时不区分大小写
+func knownOperator(op string) bool {
+	_, ok := symbolMap[strings.ToLower(op)]
+	return ok
+}
+
 func RegisterSymbol(operator string, obj IOperator) {
 	symbolMap[operator] = obj
 }
 
+// conditionDialect 返回条件渲染时使用的方言，未填充时使用全局默认方言
+func conditionDialect(w Condition) Dialect {
+	if w.Dialect != nil {
+		return w.Dialect
+	}
+	return defaultDialect
+}
+
 type likeOp struct {
 	Symbol string
 }
 
 func (r *likeOp) Operate(w Condition) (string, string, []any) {
 	placeholder, result := handleCondition(w)
+	if placeholder == "" {
+		return "", "", nil
+	}
 	return strings.ToLower(r.Symbol), placeholder, result
 }
 
@@ -73,6 +91,9 @@ type startWithOp struct {
 
 func (r *startWithOp) Operate(w Condition) (string, string, []any) {
 	placeholder, result := handleCondition(w)
+	if placeholder == "" {
+		return "", "", nil
+	}
 	return strings.ToLower(r.Symbol), placeholder, result
 }
 
@@ -82,6 +103,9 @@ type endWithOp struct {
 
 func (r *endWithOp) Operate(w Condition) (string, string, []any) {
 	placeholder, result := handleCondition(w)
+	if placeholder == "" {
+		return "", "", nil
+	}
 	return strings.ToLower(r.Symbol), placeholder, result
 }
 
@@ -89,37 +113,28 @@ type inOp struct {
 	Symbol string
 }
 
+// Operate 值为任意元素类型的切片或数组（[]byte 除外）时逐个绑定，为子查询时内联子查询；
+// 空列表和其他类型无法渲染，返回空运算符，由 parseWhere 报告为错误
 func (r *inOp) Operate(w Condition) (string, string, []any) {
-	var length int
-	var result []any
-	var placeholder string
-	switch val := w.Value.(type) {
-	case []string:
-		length = len(val)
-		for _, v := range val {
-			result = append(result, v)
-		}
-	case []int64:
-		length = len(val)
-		for _, v := range val {
-			result = append(result, v)
-		}
-	case []any:
-		length = len(val)
-		result = append(result, val...)
-	case *sqlBuilder:
-		buildQuery, args, err := val.BuildSelect()
+	if sub, ok := w.Value.(*sqlBuilder); ok {
+		buildQuery, args, err := w.subquery(sub)
 		if err != nil {
 			return "", "", nil
 		}
-		result = append(result, args...)
-		placeholder = buildQuery
-
+		return strings.ToLower(r.Symbol), fmt.Sprintf("(%s)", buildQuery), args
+	}
+	if _, ok := w.Value.([]byte); ok {
+		return "", "", nil
+	}
+	rv := reflect.ValueOf(w.Value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Len() == 0 {
+		return "", "", nil
 	}
-	if length > 0 {
-		placeholder = strings.TrimRight(strings.Repeat("?,", length), ",")
+	result := make([]any, rv.Len())
+	for i := range result {
+		result[i] = rv.Index(i).Interface()
 	}
-	placeholder = fmt.Sprintf("(%s)", placeholder)
+	placeholder := fmt.Sprintf("(%s)", strings.TrimRight(strings.Repeat("?,", len(result)), ","))
 	return strings.ToLower(r.Symbol), placeholder, result
 }
 
@@ -147,6 +162,9 @@ type equalOp struct {
 
 func (r *equalOp) Operate(w Condition) (string, string, []any) {
 	placeholder, result := handleCondition(w)
+	if placeholder == "" {
+		return "", "", nil
+	}
 	return strings.ToLower(r.Symbol), placeholder, result
 }
 
@@ -156,6 +174,9 @@ type gtOp struct {
 
 func (r *gtOp) Operate(w Condition) (string, string, []any) {
 	placeholder, result := handleCondition(w)
+	if placeholder == "" {
+		return "", "", nil
+	}
 	return strings.ToLower(r.Symbol), placeholder, result
 }
 
@@ -165,6 +186,9 @@ type ltOp struct {
 
 func (r *ltOp) Operate(w Condition) (string, string, []any) {
 	placeholder, result := handleCondition(w)
+	if placeholder == "" {
+		return "", "", nil
+	}
 	return strings.ToLower(r.Symbol), placeholder, result
 }
 
@@ -197,14 +221,16 @@ func (r *betweenOp) Operate(w Condition) (string, string, []any) {
 	}
 }
 
+// handleCondition 渲染单值条件的占位符和参数，无法渲染（子查询构建失败、不支持的值类型、
+// 无效的字段载体）时返回空占位符，调用方据此忽略条件，保证不会产生没有参数的 ?
 func handleCondition(w Condition) (string, []any) {
 	var result []any
 	var placeholder string = "?"
 	switch val := w.Value.(type) {
 	case *sqlBuilder:
-		buildQuery, args, err := val.BuildSelect()
+		buildQuery, args, err := w.subquery(val)
 		if err != nil {
-			return "?", nil
+			return "", nil
 		}
 		result = append(result, args...)
 		placeholder = fmt.Sprintf("(%v)", buildQuery)
@@ -227,22 +253,31 @@ func handleCondition(w Condition) (string, []any) {
 	case time.Time:
 		result = append(result, val)
 	case *colCarrier:
+		d := conditionDialect(w)
 		if val.Field != "" && val.TableAlias != "" {
-			placeholder = fmt.Sprintf("`%s`.`%s`", val.TableAlias, val.Field)
+			placeholder = d.Quote(val.TableAlias) + "." + d.Quote(val.Field)
 		} else if val.Field != "" {
-			placeholder = fmt.Sprintf("`%s`", val.Field)
+			placeholder = d.Quote(val.Field)
+		} else {
+			placeholder = ""
 		}
 	case *literalCarrier:
 		if val.OriginVal != "" {
 			placeholder = val.OriginVal
+		} else {
+			placeholder = ""
 		}
 	case *jsonFieldCarrier:
 		if val.Field != "" {
-			if val.TableAlias != "" {
-				placeholder = fmt.Sprintf("`%s`.`%s`%s'%s'", val.TableAlias, val.Field, val.Arrow, val.Path)
-			} else {
-				placeholder = fmt.Sprintf("`%s`%s'%s'", val.Field, val.Arrow, val.Path)
-			}
+			placeholder = val.render(conditionDialect(w))
+		} else {
+			placeholder = ""
+		}
+	default:
+		if v, ok := bindValue(val); ok {
+			result = append(result, v)
+		} else {
+			placeholder = ""
 		}
 	}
 	return placeholder, result
@@ -258,7 +293,7 @@ func (r *existsOp) Operate(w Condition) (string, string, []any) {
 	if !ok {
 		return "", "", nil
 	}
-	buildQuery, args, err := val.BuildSelect()
+	buildQuery, args, err := w.subquery(val)
 	if err != nil {
 		return "", "", nil
 	}
@@ -276,7 +311,7 @@ func (r *anyAllOp) Operate(w Condition) (string, string, []any) {
 	if !ok {
 		return "", "", nil
 	}
-	buildQuery, args, err := val.BuildSelect()
+	buildQuery, args, err := w.subquery(val)
 	if err != nil {
 		return "", "", nil
 	}
@@ -306,12 +341,16 @@ func (r *multiInOp) Operate(w Condition) (string, string, []any) {
 type findInSetOp struct{}
 
 func (r *findInSetOp) Operate(w Condition) (string, string, []any) {
+	d := conditionDialect(w)
+	if !d.Supports(FeatureFindInSet) {
+		return "", "", nil
+	}
 	placeholder, result := handleCondition(w)
 	tableName := w.TableName
-	if tableName == "" {
+	if tableName == "" || placeholder == "" {
 		return "", "", nil
 	}
-	return "find_in_set", fmt.Sprintf("(%s, `%s`.`%s`)", placeholder, tableName, w.Field), result
+	return "find_in_set", fmt.Sprintf("(%s, %s.%s)", placeholder, d.Quote(tableName), d.Quote(w.Field)), result
 }
 
 // jsonExtractOp JSON_EXTRACT 操作符（MySQL）
@@ -326,5 +365,7 @@ func (r *jsonExtractOp) Operate(w Condition) (string, string, []any) {
 	if tableName == "" {
 		return "", "", nil
 	}
-	return "json_extract", fmt.Sprintf("(`%s`.`%s`, '%s')", tableName, w.Field, val), nil
+	d := conditionDialect(w)
+	// 路径以参数绑定，不拼接到 SQL 中
+	return "json_extract", fmt.Sprintf("(%s.%s, ?)", d.Quote(tableName), d.Quote(w.Field)), []any{val}
 }
@@ -0,0 +1,82 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// BuildStructUpdateByPK 使用结构体构建按主键更新的 SQL
+// 以 pk 选项标记的字段（如 `db:"id,pk"`）作为条件，不出现在 SET 中，其他字段规则同 BuildStructUpdate
+// 已有的 WHERE 条件会整体与主键条件以 AND 组合；主键为零值时返回错误
+func (b *sqlBuilder) BuildStructUpdateByPK(entity any) (sqlStr string, args []any, err error) {
+	defer b.observe("BuildStructUpdateByPK")(&sqlStr, &args, &err)
+	c, err := b.wherePK(entity)
+	if err != nil {
+		return "", nil, err
+	}
+	return c.buildStructUpdate(entity, structUpdateByPK)
+}
+
+// BuildStructDeleteByPK 使用结构体的主键构建 DELETE SQL，主键为零值时返回错误
+func (b *sqlBuilder) BuildStructDeleteByPK(entity any) (sqlStr string, args []any, err error) {
+	defer b.observe("BuildStructDeleteByPK")(&sqlStr, &args, &err)
+	c, err := b.wherePK(entity)
+	if err != nil {
+		return "", nil, err
+	}
+	c.nested = true
+	return c.BuildDelete()
+}
+
+// wherePK 返回添加了主键条件的副本，原 builder 不受影响
+func (b *sqlBuilder) wherePK(entity any) (*sqlBuilder, error) {
+	if err := b.checkErr(); err != nil {
+		return nil, err
+	}
+	reflectVal := reflect.ValueOf(entity)
+	if reflectVal.Kind() != reflect.Ptr || reflectVal.IsNil() || reflectVal.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("需要传入结构体指针")
+	}
+	c := b.Clone()
+	n, err := c.recursionPK(reflectVal.Elem())
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("结构体 %s 没有标记 pk 的字段", reflectVal.Elem().Type())
+	}
+	return c, c.err
+}
+
+// recursionPK 递归查找 pk 字段并添加为主键条件，返回主键字段的数量
+func (b *sqlBuilder) recursionPK(reflectVal reflect.Value) (int, error) {
+	n := 0
+	typ := reflectVal.Type()
+	for i := 0; i < reflectVal.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			m, err := b.recursionPK(reflectVal.Field(i))
+			if err != nil {
+				return 0, err
+			}
+			n += m
+			continue
+		}
+		tag := parseFieldTag(field.Tag.Get(b.GetDbTag()))
+		if tag.ignored() || !tag.pk || !field.IsExported() {
+			continue
+		}
+		if !isSafeIdentifier(tag.name) {
+			return 0, fmt.Errorf("非法的主键字段: %s", tag.name)
+		}
+		fieldVal := reflectVal.Field(i)
+		if isEmptyValue(fieldVal) {
+			return 0, fmt.Errorf("主键 %s 不能为零值", tag.name)
+		}
+		b.pkConds = append(b.pkConds, fmt.Sprintf("%s = ?", b.quoteCol(b.condTable(), tag.name)))
+		b.pkArgs = append(b.pkArgs, structFieldValue(fieldVal))
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,42 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returning 设置 RETURNING 子句，用于 INSERT/UPDATE/DELETE 一次往返取回生成的主键或更新后的行
+// 传入 "*" 返回所有列；方言不支持时构建报错
+func (b *sqlBuilder) Returning(columns ...string) *sqlBuilder {
+	if len(columns) == 0 {
+		b.err = fmt.Errorf("RETURNING 列不能为空")
+		return b
+	}
+	for _, col := range columns {
+		if col != "*" && !isSafeIdentifier(col) {
+			b.err = fmt.Errorf("非法的 RETURNING 列名: %s", col)
+			return b
+		}
+	}
+	b.returning = columns
+	return b
+}
+
+// appendReturning 追加 RETURNING 子句，f 为当前语句需要的方言特性
+func (b *sqlBuilder) appendReturning(sqlStr string, f Feature) (string, error) {
+	if len(b.returning) == 0 {
+		return sqlStr, nil
+	}
+	if err := b.require(f, "returning"); err != nil {
+		return "", err
+	}
+	cols := make([]string, len(b.returning))
+	for i, col := range b.returning {
+		if col == "*" {
+			cols[i] = col
+		} else {
+			cols[i] = b.quote(col)
+		}
+	}
+	return fmt.Sprintf("%s returning %s", sqlStr, strings.Join(cols, ", ")), nil
+}
@@ -0,0 +1,117 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Builder 导出的 builder 类型，用于在包外声明回调参数，如 RegisterScope 的 func(*sqlbuilder.Builder)
+type Builder = sqlBuilder
+
+// namedScope 注册到表上的命名范围
+type namedScope struct {
+	name string
+	fn   func(*sqlBuilder)
+}
+
+// scopeRegistry 表名到命名范围的注册表
+var scopeRegistry = struct {
+	sync.RWMutex
+	tables map[string][]namedScope
+}{tables: make(map[string][]namedScope)}
+
+// RegisterScope 为表注册命名范围（如租户、可见性条件），From(table) 创建的 builder 在
+// BuildSelect（含 COUNT/EXISTS/子查询）、UPDATE 和 DELETE 构建时自动应用，可用 WithoutScope 按名称移除
+// 范围在构建时作用于 builder 的副本，追加的条件排在已有条件之后；同名范围重复注册时替换
+//
+//	sqlbuilder.RegisterScope("order", "tenant", func(b *sqlbuilder.Builder) {
+//		b.WhereAnd("tenant_id", currentTenant())
+//	})
+func RegisterScope(table, name string, fn func(*sqlBuilder)) {
+	scopeRegistry.Lock()
+	defer scopeRegistry.Unlock()
+	list := scopeRegistry.tables[table]
+	for i, s := range list {
+		if s.name == name {
+			list[i].fn = fn
+			return
+		}
+	}
+	scopeRegistry.tables[table] = append(list, namedScope{name: name, fn: fn})
+}
+
+// UnregisterScope 移除表上的命名范围
+func UnregisterScope(table, name string) {
+	scopeRegistry.Lock()
+	defer scopeRegistry.Unlock()
+	list := scopeRegistry.tables[table]
+	for i, s := range list {
+		if s.name == name {
+			scopeRegistry.tables[table] = append(list[:i:i], list[i+1:]...)
+			return
+		}
+	}
+}
+
+// WithoutScope 当前查询不应用指定名称的范围
+func (b *sqlBuilder) WithoutScope(names ...string) *sqlBuilder {
+	for _, name := range names {
+		if name == "" {
+			b.err = fmt.Errorf("范围名称不能为空")
+			return b
+		}
+		if b.withoutScopes == nil {
+			b.withoutScopes = make(map[string]bool)
+		}
+		b.withoutScopes[name] = true
+	}
+	return b
+}
+
+// pendingScopes 返回本次构建需要应用的范围
+func (b *sqlBuilder) pendingScopes() []func(*sqlBuilder) {
+	if b.scopesApplied || b.tableName == "" {
+		return nil
+	}
+	scopeRegistry.RLock()
+	defer scopeRegistry.RUnlock()
+	var fns []func(*sqlBuilder)
+	for _, s := range scopeRegistry.tables[b.tableName] {
+		if !b.withoutScopes[s.name] {
+			fns = append(fns, s.fn)
+		}
+	}
+	return fns
+}
+
+// applyScopes 在副本上应用范围
+// 范围的条件单独收集并渲染，再整体以 AND 追加在用户条件之后，避免被用户的 or 条件绕过
+func (b *sqlBuilder) applyScopes(fns []func(*sqlBuilder)) {
+	b.scopesApplied = true
+	whr, parts, pkConds := b.whr, b.customParts, b.pkConds
+	b.pkConds = nil
+	b.whr = &Where{
+		tableName:     b.tableName,
+		alias:         b.alias,
+		groupWhere:    make([]GroupWhere, 0),
+		assembleWhere: make([][]GroupWhere, 0),
+	}
+	b.customParts = nil
+	for _, fn := range fns {
+		fn(b)
+	}
+	cond, args, err := b.buildWhere()
+	if andParts(b.customParts) {
+		b.scopeWhr = b.whr
+	}
+	b.whr, b.customParts, b.pkConds = whr, parts, pkConds
+	if err != nil {
+		b.err = err
+		return
+	}
+	// 范围中可能含有原始片段，无法可靠判断是否有 or，总是整体加括号
+	if cond != "" {
+		cond = "(" + cond + ")"
+	}
+	b.scopeCond, b.scopeArgs = cond, args
+}
@@ -0,0 +1,37 @@
+//go:build go1.21
+
+package sqlbuilder
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHook 返回将构建结果写入 slog 的钩子：成功时以 level 级别记录，出错时以 Error 级别记录
+//
+//	sqlbuilder.AddHook(sqlbuilder.SlogHook(slog.Default(), slog.LevelDebug))
+func SlogHook(l *slog.Logger, level slog.Level) Hook {
+	return slogHook{l: l, level: level}
+}
+
+type slogHook struct {
+	l     *slog.Logger
+	level slog.Level
+}
+
+func (h slogHook) BeforeBuild(e *BuildEvent) {}
+
+func (h slogHook) AfterBuild(e *BuildEvent) {
+	attrs := []slog.Attr{
+		slog.String("op", e.Op),
+		slog.String("table", e.Table),
+		slog.Duration("duration", e.Duration),
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.Any("error", e.Err))
+		h.l.LogAttrs(context.Background(), slog.LevelError, "sqlbuilder build failed", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.String("sql", e.SQL), slog.Any("args", e.Args))
+	h.l.LogAttrs(context.Background(), h.level, "sqlbuilder build", attrs...)
+}
@@ -0,0 +1,25 @@
+//go:build go1.21
+
+package sqlbuilder
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHook(t *testing.T) {
+	var buf strings.Builder
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	From("user").Hook(SlogHook(l, slog.LevelDebug)).WhereAnd("id", 1).BuildSelect()
+	out := buf.String()
+	if !strings.Contains(out, "level=DEBUG") || !strings.Contains(out, "op=BuildSelect") || !strings.Contains(out, "table=user") || !strings.Contains(out, "args=[1]") {
+		t.Errorf("unexpected log: %s", out)
+	}
+
+	buf.Reset()
+	From("user").Hook(SlogHook(l, slog.LevelDebug)).BuildDelete()
+	if out := buf.String(); !strings.Contains(out, "level=ERROR") || !strings.Contains(out, "error=") {
+		t.Errorf("unexpected log: %s", out)
+	}
+}
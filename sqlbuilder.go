@@ -40,10 +40,8 @@ type sqlBuilder struct {
 	// sql 语句
 	SqlStr string
 
-	// 子查询
-	childQuery string
-	// FROM 子查询的参数（延迟到 buildFromClause 添加）
-	fromArgs []any
+	// FROM 子查询（延迟到构建时按方言渲染）
+	fromQuery *sqlBuilder
 
 	// 是否去重
 	distinct bool
@@ -66,7 +64,8 @@ type sqlBuilder struct {
 	// SQL 提示
 	sqlHints []string
 	// 索引提示
-	indexHint string
+	indexHintType string
+	indexHints    []string
 	// 行锁
 	lockClause string
 	// UNION 子句
@@ -78,15 +77,72 @@ type sqlBuilder struct {
 	withRollup bool
 	// ON DUPLICATE KEY UPDATE
 	onDuplicateUpdates map[string]any
-	// 原始 SQL 片段
-	customParts []string
+	onDuplicateAll     bool
+	onDuplicateExcept  []string
+	// INSERT ... AS alias 行别名
+	rowAlias string
+	// RETURNING 列
+	returning []string
+	// ON CONFLICT（PostgreSQL / SQLite）
+	conflict *conflictClause
+	// 原始 SQL 片段及其参数
+	customParts []customPart
+	// map 列的输出顺序，未指定的列按字母序排在后面
+	columnOrder []string
+	// 批量 map 插入时各行列集合不一致的处理方式及缺失列的默认值
+	rowKeysMode int
+	rowDefaults map[string]any
 	// 软删除字段
 	softDeleteField string
+	// 软删除范围：排除、包含或仅查询已软删除的行
+	trashed int
+	// 乐观锁版本号字段
+	versionField string
+	// WithoutScope 排除的范围名称
+	withoutScopes map[string]bool
+	// 注册的范围是否已应用到当前副本
+	scopesApplied bool
+	// 范围渲染后的条件及参数，仅存在于 prepare 后的副本
+	scopeCond string
+	scopeArgs []any
+	// 范围的条件树，供 RequireCondition 检查
+	scopeWhr *Where
+	// 当前 builder 的构建钩子
+	hooks []Hook
+	// 在外层 Build* 内部调用，不重复触发钩子
+	nested bool
+	// 构建 UPDATE 时的乐观锁状态，仅存在于 prepare 后的副本
+	version *versionLock
+	// 按主键更新、删除时的主键条件及参数，仅存在于 wherePK 返回的副本
+	pkConds []string
+	pkArgs  []any
+
+	// SQL 方言，nil 表示使用全局默认方言
+	dialect Dialect
+
+	// 严格模式：被静默忽略的输入在构建时作为错误返回
+	strict bool
+	// 被静默忽略的输入
+	skipped []error
 
 	// 构建过程中的错误
 	err error
 }
 
+// customPart 原始 SQL 片段
+type customPart struct {
+	sql  string
+	args []any
+}
+
+// 全局默认的严格模式
+var defaultStrict bool
+
+// SetDefaultStrict 设置全局默认的严格模式
+func SetDefaultStrict(strict bool) {
+	defaultStrict = strict
+}
+
 // From 创建一个 sqlBuilder 实例
 func From(tableName string, args ...any) *sqlBuilder {
 	if !isSafeIdentifier(tableName) {
@@ -112,13 +168,11 @@ func From(tableName string, args ...any) *sqlBuilder {
 	}
 	if len(args) > 0 {
 		if val, ok := args[0].(*sqlBuilder); ok {
-			childQuery, data, err := val.BuildSelect()
-			if err != nil {
-				builder.err = err
+			if val.err != nil {
+				builder.err = val.err
 				return builder
 			}
-			builder.fromArgs = data
-			builder.childQuery = childQuery
+			builder.fromQuery = val
 		}
 	}
 	return builder
@@ -142,12 +196,180 @@ func (b *sqlBuilder) Table(tableName string) *sqlBuilder {
 	return b
 }
 
-// Debug 开启 SQL 打印，调试用
+// Debug 开启 SQL 打印，调试用，所有 Build* 方法的结果输出到 SetDebugLogger 设置的日志
 func (b *sqlBuilder) Debug() *sqlBuilder {
 	b.debugSql = true
 	return b
 }
 
+// Dialect 设置 SQL 方言，如 From("user").Dialect(Postgres)
+func (b *sqlBuilder) Dialect(d Dialect) *sqlBuilder {
+	if d == nil {
+		b.err = errors.New("方言不能为 nil")
+		return b
+	}
+	b.dialect = d
+	return b
+}
+
+// Strict 开启严格模式，任何被静默忽略的片段都会使 Build* 返回错误
+func (b *sqlBuilder) Strict() *sqlBuilder {
+	b.strict = true
+	return b
+}
+
+// isStrict 是否处于严格模式（builder 选项或全局默认）
+func (b *sqlBuilder) isStrict() bool {
+	return b.strict || defaultStrict
+}
+
+// skip 记录被静默忽略的输入，严格模式下构建时返回
+func (b *sqlBuilder) skip(errs ...error) {
+	b.skipped = append(b.skipped, errs...)
+}
+
+// checkErr 返回构建前已存在的错误，严格模式下包含被忽略的输入
+func (b *sqlBuilder) checkErr() error {
+	if b.err != nil {
+		return b.err
+	}
+	if b.isStrict() && len(b.skipped) > 0 {
+		return fmt.Errorf("严格模式: %w", b.skipped[0])
+	}
+	return nil
+}
+
+// prepare 返回本次构建使用的浅拷贝，构建产生的参数和中间状态只写入拷贝，
+// 保证同一个 builder 可以重复调用 Build*
+// 表上注册了范围时返回应用范围后的深拷贝，范围和 RequireCondition 检查产生的错误由调用方的 checkErr 返回
+func (b *sqlBuilder) prepare() *sqlBuilder {
+	var c *sqlBuilder
+	if fns := b.pendingScopes(); len(fns) > 0 {
+		c = b.Clone()
+		c.applyScopes(fns)
+	} else {
+		cp := *b
+		c = &cp
+	}
+	c.fieldValue = nil
+	c.SqlStr = ""
+	if c.err == nil {
+		c.err = c.checkGuard()
+	}
+	return c
+}
+
+// buildWhere 渲染 WHERE 条件，原始条件和自定义 SQL 片段按添加顺序追加在后
+func (b *sqlBuilder) buildWhere() (string, []any, error) {
+	whStr, args, err := b.parseCond(b.whr)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, cp := range b.customParts {
+		if whStr == "" {
+			whStr = strings.TrimPrefix(strings.TrimPrefix(cp.sql, "and "), "or ")
+		} else {
+			whStr = fmt.Sprintf("%s %s", whStr, cp.sql)
+		}
+		args = append(args, cp.args...)
+	}
+	// 主键条件以 AND 追加，用户条件整体加括号，不会被 or 分支绕过
+	if len(b.pkConds) > 0 {
+		whStr = appendConds(whStr, b.pkConds...)
+		args = append(args, b.pkArgs...)
+	}
+	return whStr, args, nil
+}
+
+// extraConds 返回以 AND 追加在用户条件之后的条件：注册的范围、软删除范围和乐观锁版本号
+// 在"必须有条件"的校验之后追加，这些条件不能代替用户条件
+func (b *sqlBuilder) extraConds() []string {
+	var conds []string
+	if b.scopeCond != "" {
+		conds = append(conds, b.scopeCond)
+	}
+	conds = append(conds, b.softDeleteConds()...)
+	if b.version != nil {
+		conds = append(conds, b.versionCond())
+	}
+	return conds
+}
+
+// extraArgs 返回 extraConds 的参数，追加在用户条件参数之后
+func (b *sqlBuilder) extraArgs() []any {
+	args := b.scopeArgs
+	if b.version != nil {
+		args = append(args[:len(args):len(args)], b.version.current)
+	}
+	return args
+}
+
+// condTable 返回条件中引用当前表使用的名称
+func (b *sqlBuilder) condTable() string {
+	if b.alias != "" {
+		return b.alias
+	}
+	return b.tableName
+}
+
+// appendConds 以 AND 追加条件，whStr 总是整体加括号：其中可能含有原始片段，无法可靠判断是否有 or
+func appendConds(whStr string, conds ...string) string {
+	if len(conds) == 0 {
+		return whStr
+	}
+	if whStr == "" {
+		return strings.Join(conds, " and ")
+	}
+	return "(" + whStr + ") and " + strings.Join(conds, " and ")
+}
+
+// parseCond 按当前方言渲染条件，无法渲染的条件总是视为错误：忽略条件会扩大查询、更新和删除的范围
+func (b *sqlBuilder) parseCond(w *Where) (string, []any, error) {
+	whStr, args, err := w.parseWhere(b.getDialect())
+	if err != nil {
+		return "", nil, err
+	}
+	return whStr, args, nil
+}
+
+// getDialect 返回当前生效的方言
+func (b *sqlBuilder) getDialect() Dialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return defaultDialect
+}
+
+// quote 按方言引用标识符
+func (b *sqlBuilder) quote(ident string) string {
+	return b.getDialect().Quote(ident)
+}
+
+// quoteCol 按方言引用 table.field
+func (b *sqlBuilder) quoteCol(table, field string) string {
+	d := b.getDialect()
+	return d.Quote(table) + "." + d.Quote(field)
+}
+
+// require 校验当前方言是否支持某项特性
+func (b *sqlBuilder) require(f Feature, what string) error {
+	if d := b.getDialect(); !d.Supports(f) {
+		return fmt.Errorf("%s 方言不支持 %s", d.Name(), what)
+	}
+	return nil
+}
+
+// subquery 构建子查询，子查询未显式指定方言时继承父查询的方言
+func (b *sqlBuilder) subquery(d Dialect) (string, []any, error) {
+	sub := b
+	if b.dialect == nil && d != nil {
+		c := *b
+		c.dialect = d
+		sub = &c
+	}
+	return sub.buildSelect()
+}
+
 // 查询字段
 func (b *sqlBuilder) Select(fields ...any) *sqlBuilder {
 	for _, f := range fields {
@@ -157,11 +379,92 @@ func (b *sqlBuilder) Select(fields ...any) *sqlBuilder {
 				return b
 			}
 		}
+		if err := carrierErr(f); err != nil {
+			b.skip(fmt.Errorf("查询字段被忽略: %w", err))
+		}
 	}
 	b.fields = fields
 	return b
 }
 
+// SelectStruct 按结构体的 db tag 追加查询字段，如 `user`.`id` as `id`
+// 匿名嵌入结构体的字段视为外层字段；带 db tag 的具名嵌套结构体（如 Dept Dept `db:"d"`）
+// 以 tag 为表别名展开，如 `d`.`id` as `d.id`，需要联表并使用该别名，entity 为结构体或结构体指针
+func (b *sqlBuilder) SelectStruct(entity any) *sqlBuilder {
+	return b.selectStruct(entity, b.alias, false)
+}
+
+// SelectStructAs 按结构体的 db tag 追加指定表别名的查询字段，如 `d`.`id` as `d.id`
+// 列别名带表别名前缀，联表时不会与主表字段重名，executor 扫描时可映射到 db:"d" 的嵌套结构体字段
+func (b *sqlBuilder) SelectStructAs(entity any, tableAlias string) *sqlBuilder {
+	if !isSafeIdentifier(tableAlias) {
+		b.err = fmt.Errorf("非法的别名: %s", tableAlias)
+		return b
+	}
+	return b.selectStruct(entity, tableAlias, true)
+}
+
+func (b *sqlBuilder) selectStruct(entity any, tableAlias string, prefixed bool) *sqlBuilder {
+	t := reflect.TypeOf(entity)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		b.err = errors.New("SelectStruct 参数必须是结构体或结构体指针")
+		return b
+	}
+	prefix := ""
+	if prefixed {
+		prefix = tableAlias + "."
+	}
+	for _, col := range structColumns(t, b.GetDbTag(), tableAlias, prefix) {
+		if !isSafeIdentifierAny(col.Field, col.TableAlias) {
+			b.err = fmt.Errorf("非法的查询字段: %s.%s", col.TableAlias, col.Field)
+			return b
+		}
+		b.fields = append(b.fields, col)
+	}
+	return b
+}
+
+// structColumns 按 db tag 收集结构体的查询字段，与 executor 扫描时的字段映射一致：
+// 匿名嵌入结构体（或结构体指针）且没有 tag 时视为外层字段；
+// 带 tag 的嵌套结构体以 tag 为表别名递归展开，列别名追加 tag. 前缀
+func structColumns(t reflect.Type, tag, tableAlias, prefix string) []*colCarrier {
+	var cols []*colCarrier
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		dbTag := parseFieldTag(field.Tag.Get(tag))
+		nested := isNestedStruct(ft)
+		if field.Anonymous && nested && dbTag.name == "" {
+			cols = append(cols, structColumns(ft, tag, tableAlias, prefix)...)
+			continue
+		}
+		if dbTag.ignored() || !field.IsExported() {
+			continue
+		}
+		if nested {
+			cols = append(cols, structColumns(ft, tag, dbTag.name, prefix+dbTag.name+".")...)
+			continue
+		}
+		cols = append(cols, &colCarrier{TableAlias: tableAlias, Field: dbTag.name, FieldAlias: prefix + dbTag.name})
+	}
+	return cols
+}
+
+// isNestedStruct 是否为需要展开的嵌套结构体，time.Time、sql.Scanner 和 driver.Valuer 作为单列处理
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	return !pt.Implements(scannerType) && !pt.Implements(valuerType)
+}
+
 // Offset 设置原始偏移量（需配合 Size 或 LimitRaw 使用）
 func (b *sqlBuilder) Offset(n int64) *sqlBuilder {
 	if n < 0 {
@@ -211,6 +514,9 @@ func (b *sqlBuilder) Limit(n int64) *sqlBuilder {
 // 排序
 func (b *sqlBuilder) Order(order [][]any) *sqlBuilder {
 	for _, v := range order {
+		if len(v) < 2 || len(v) > 3 {
+			b.skip(fmt.Errorf("排序条件格式错误，已被忽略: %v", v))
+		}
 		if len(v) >= 1 {
 			if s, ok := v[0].(string); ok && !isSafeIdentifier(s) {
 				b.err = fmt.Errorf("非法的排序字段: %s", s)
@@ -371,10 +677,14 @@ func (b *sqlBuilder) WhereOr(args ...any) *sqlBuilder {
 *
 */
 func (b *sqlBuilder) where(relation string, args ...any) *sqlBuilder {
-	// 检查标识符参数不包含反引号
+	// 只校验标识符和运算符，条件值以占位符绑定
 	if len(args) > 0 {
-		if s, ok := args[0].(string); ok && s != "" && !isSafeIdentifier(s) {
-			b.err = fmt.Errorf("非法的 WHERE 字段名: %s", s)
+		if err := checkConditionArgs("WHERE", args...); err != nil {
+			b.err = err
+			return b
+		}
+		if errs := skippedConditions("WHERE", args...); len(errs) > 0 {
+			b.err = errs[0]
 			return b
 		}
 	}
@@ -389,10 +699,14 @@ func (b *sqlBuilder) where(relation string, args ...any) *sqlBuilder {
 
 // 设置having条件
 func (b *sqlBuilder) havingWhere(relation string, args ...any) *sqlBuilder {
-	// 检查标识符参数不包含反引号
+	// 只校验标识符和运算符，条件值以占位符绑定
 	if len(args) > 0 {
-		if s, ok := args[0].(string); ok && s != "" && !isSafeIdentifier(s) {
-			b.err = fmt.Errorf("非法的 HAVING 字段名: %s", s)
+		if err := checkConditionArgs("HAVING", args...); err != nil {
+			b.err = err
+			return b
+		}
+		if errs := skippedConditions("HAVING", args...); len(errs) > 0 {
+			b.err = errs[0]
 			return b
 		}
 	}
@@ -407,15 +721,13 @@ func (b *sqlBuilder) havingWhere(relation string, args ...any) *sqlBuilder {
 
 // WhereRaw 添加原始 WHERE 条件（绕过安全检查，慎用）
 func (b *sqlBuilder) WhereRaw(condition string, args ...any) *sqlBuilder {
-	b.customParts = append(b.customParts, "and ("+condition+")")
-	b.fieldValue = append(b.fieldValue, args...)
+	b.customParts = append(b.customParts, customPart{sql: "and (" + condition + ")", args: args})
 	return b
 }
 
 // WhereRawOr 添加原始 OR WHERE 条件（绕过安全检查，慎用）
 func (b *sqlBuilder) WhereRawOr(condition string, args ...any) *sqlBuilder {
-	b.customParts = append(b.customParts, "or ("+condition+")")
-	b.fieldValue = append(b.fieldValue, args...)
+	b.customParts = append(b.customParts, customPart{sql: "or (" + condition + ")", args: args})
 	return b
 }
 
@@ -435,25 +747,67 @@ func (b *sqlBuilder) OnDuplicateKey(updates map[string]any) *sqlBuilder {
 	return b
 }
 
+// OnDuplicateKeyUpdateAll 冲突时更新所有插入的列，except 中的列（通常是主键、唯一键）除外
+// 未设置 RowAlias 时渲染为 col = VALUES(col)，否则渲染为 col = alias.col
+// 可与 OnDuplicateKey 同时使用，OnDuplicateKey 中设置的列优先
+func (b *sqlBuilder) OnDuplicateKeyUpdateAll(except ...string) *sqlBuilder {
+	if !isSafeIdentifierAny(except...) {
+		b.err = fmt.Errorf("非法的列名")
+		return b
+	}
+	b.onDuplicateAll = true
+	b.onDuplicateExcept = except
+	return b
+}
+
+// RowAlias 设置 INSERT ... VALUES (...) AS alias 行别名（MySQL 8.0.19+），
+// ON DUPLICATE KEY UPDATE 中可通过 SField(alias, col, "") 引用待插入的值
+func (b *sqlBuilder) RowAlias(alias string) *sqlBuilder {
+	if !isSafeIdentifier(alias) {
+		b.err = fmt.Errorf("非法的行别名: %s", alias)
+		return b
+	}
+	b.rowAlias = alias
+	return b
+}
+
+// ColumnOrder 指定 map 类 INSERT/UPDATE/ON DUPLICATE KEY 的列输出顺序
+// 未指定的列按字母序排在后面；不调用时全部按字母序输出
+func (b *sqlBuilder) ColumnOrder(columns ...string) *sqlBuilder {
+	if !isSafeIdentifierAny(columns...) {
+		b.err = fmt.Errorf("非法的列名")
+		return b
+	}
+	b.columnOrder = columns
+	return b
+}
+
 // Raw 添加原始 SQL 片段（绕过所有安全检查，慎用）
 func (b *sqlBuilder) Raw(sql string, args ...any) *sqlBuilder {
-	b.customParts = append(b.customParts, sql)
-	b.fieldValue = append(b.fieldValue, args...)
+	b.customParts = append(b.customParts, customPart{sql: sql, args: args})
 	return b
 }
 
-// 返回where条件和参数
+// 返回where条件和参数，参数追加到 GetFieldValue 的结果中
 func (b *sqlBuilder) ToString() string {
-	sqlStr, args := b.whr.ParseWhere()
+	sqlStr, args, _ := b.whr.parseWhere(b.getDialect())
 	b.fieldValue = append(b.fieldValue, args...)
 	return sqlStr
 }
 
-// 获取字段值
+// 获取 ToString 累积的字段值
 func (b *sqlBuilder) GetFieldValue() []any {
 	return b.fieldValue
 }
 
+// GetDbTag 获取结构体映射使用的 tag 名，未设置时为 db
+func (b *sqlBuilder) GetDbTag() string {
+	if b.dbTag == "" {
+		return "db"
+	}
+	return b.dbTag
+}
+
 // As 给表起别名
 func (b *sqlBuilder) As(name string) *sqlBuilder {
 	if !isSafeIdentifier(name) {
@@ -480,40 +834,34 @@ func (b *sqlBuilder) LockInShareMode() *sqlBuilder {
 
 // UseIndex 添加 USE INDEX 索引提示
 func (b *sqlBuilder) UseIndex(indexes ...string) *sqlBuilder {
-	quoted := make([]string, len(indexes))
-	for i, idx := range indexes {
-		if !isSafeIdentifier(idx) {
-			return b
-		}
-		quoted[i] = fmt.Sprintf("`%s`", idx)
+	if !isSafeIdentifierAny(indexes...) {
+		b.skip(fmt.Errorf("非法的索引名，索引提示已被忽略: %v", indexes))
+		return b
 	}
-	b.indexHint = fmt.Sprintf("use index (%s)", strings.Join(quoted, ", "))
+	b.indexHintType = "use"
+	b.indexHints = indexes
 	return b
 }
 
 // ForceIndex 添加 FORCE INDEX 索引提示
 func (b *sqlBuilder) ForceIndex(indexes ...string) *sqlBuilder {
-	quoted := make([]string, len(indexes))
-	for i, idx := range indexes {
-		if !isSafeIdentifier(idx) {
-			return b
-		}
-		quoted[i] = fmt.Sprintf("`%s`", idx)
+	if !isSafeIdentifierAny(indexes...) {
+		b.skip(fmt.Errorf("非法的索引名，索引提示已被忽略: %v", indexes))
+		return b
 	}
-	b.indexHint = fmt.Sprintf("force index (%s)", strings.Join(quoted, ", "))
+	b.indexHintType = "force"
+	b.indexHints = indexes
 	return b
 }
 
 // IgnoreIndex 添加 IGNORE INDEX 索引提示
 func (b *sqlBuilder) IgnoreIndex(indexes ...string) *sqlBuilder {
-	quoted := make([]string, len(indexes))
-	for i, idx := range indexes {
-		if !isSafeIdentifier(idx) {
-			return b
-		}
-		quoted[i] = fmt.Sprintf("`%s`", idx)
+	if !isSafeIdentifierAny(indexes...) {
+		b.skip(fmt.Errorf("非法的索引名，索引提示已被忽略: %v", indexes))
+		return b
 	}
-	b.indexHint = fmt.Sprintf("ignore index (%s)", strings.Join(quoted, ", "))
+	b.indexHintType = "ignore"
+	b.indexHints = indexes
 	return b
 }
 
@@ -525,38 +873,65 @@ func (b *sqlBuilder) Reset() *sqlBuilder {
 	b.groupBy = nil
 	b.joins = nil
 	b.SqlStr = ""
-	b.childQuery = ""
 	b.distinct = false
 	b.offset = 0
 	b.pageSize = 0
 	b.debugSql = false
+	b.hooks = nil
 	b.dbTag = ""
 	b.emptyFieldMap = make(map[string]bool)
 	b.zeroFieldMap = make(map[string]bool)
 	b.sqlHints = nil
-	b.indexHint = ""
+	b.indexHintType = ""
+	b.indexHints = nil
 	b.lockClause = ""
 	b.unions = nil
 	b.ctes = nil
 	b.recursive = false
 	b.withRollup = false
 	b.onDuplicateUpdates = nil
+	b.onDuplicateAll = false
+	b.onDuplicateExcept = nil
+	b.rowAlias = ""
+	b.returning = nil
+	b.conflict = nil
 	b.customParts = nil
+	b.columnOrder = nil
+	b.rowKeysMode = rowKeysFirst
+	b.rowDefaults = nil
 	b.softDeleteField = ""
-	b.fromArgs = nil
+	b.trashed = trashedExclude
+	b.versionField = ""
+	b.withoutScopes = nil
+	b.fromQuery = nil
+	b.skipped = nil
 	b.err = nil
-	// tableName/alias 保留，因为 From 时已设置，Reset 后通常复用同一表
+	// tableName/alias/dialect/strict 保留，因为 From 时已设置，Reset 后通常复用同一表
 	return b
 }
 
 // BuildSelect 构建 SELECT 查询 SQL，返回 SQL 语句和参数值列表
-func (b *sqlBuilder) BuildSelect() (string, []any, error) {
-	if b.err != nil {
-		return "", nil, b.err
+func (b *sqlBuilder) BuildSelect() (sqlStr string, args []any, err error) {
+	defer b.observe("BuildSelect")(&sqlStr, &args, &err)
+	sqlStr, args, err = b.buildSelect()
+	if err != nil {
+		return "", nil, err
+	}
+	return rebind(b.getDialect(), sqlStr), args, nil
+}
+
+// buildSelect 构建 SELECT 查询，占位符统一为 ?，由外层按方言替换
+func (b *sqlBuilder) buildSelect() (string, []any, error) {
+	b = b.prepare()
+	if err := b.checkErr(); err != nil {
+		return "", nil, err
 	}
+	d := b.getDialect()
 	if b.alias == "" {
 		b.alias = b.tableName
-		b.whr.SetAlias(b.tableName)
+	}
+	if err := b.checkSelectFeatures(); err != nil {
+		return "", nil, err
 	}
 
 	// CTE (must accumulate args first, before SELECT)
@@ -571,14 +946,12 @@ func (b *sqlBuilder) BuildSelect() (string, []any, error) {
 		return "", nil, err
 	}
 
-	// FROM 子句 — 此时才把 FROM 子查询的参数加入（在 CTE 和 SELECT 之后）
-	if len(b.fromArgs) > 0 {
-		b.fieldValue = append(b.fieldValue, b.fromArgs...)
+	// FROM 子句 — 此时才构建 FROM 子查询并加入参数（在 CTE 和 SELECT 之后）
+	from, err := b.buildFromClause()
+	if err != nil {
+		return "", nil, err
 	}
 
-	// FROM 子句
-	from := b.buildFromClause()
-
 	// SELECT 前缀
 	selectPrefix := b.buildSelectPrefix()
 
@@ -589,25 +962,19 @@ func (b *sqlBuilder) BuildSelect() (string, []any, error) {
 		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, joinStr)
 	}
 
-	// WHERE
-	whStr, whValue := b.whr.ParseWhere()
-	hasWhere := whStr != ""
-	if hasWhere {
+	// WHERE，含原始条件和自定义 SQL 片段
+	whStr, whValue, err := b.buildWhere()
+	if err != nil {
+		return "", nil, err
+	}
+	whStr = appendConds(whStr, b.extraConds()...)
+	if whStr != "" {
 		b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, whStr)
 	}
 	if len(whValue) > 0 {
 		b.fieldValue = append(b.fieldValue, whValue...)
 	}
-
-	// raw conditions and custom SQL fragments
-	for _, cp := range b.customParts {
-		if !hasWhere {
-			b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, strings.TrimPrefix(strings.TrimPrefix(cp, "and "), "or "))
-			hasWhere = true
-		} else {
-			b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, cp)
-		}
-	}
+	b.fieldValue = append(b.fieldValue, b.extraArgs()...)
 
 	// GROUP BY
 	if gb := b.buildGroupBy(); gb != "" {
@@ -615,7 +982,10 @@ func (b *sqlBuilder) BuildSelect() (string, []any, error) {
 	}
 
 	// HAVING
-	hwhStr, hwhValue := b.hhr.ParseWhere()
+	hwhStr, hwhValue, err := b.parseCond(b.hhr)
+	if err != nil {
+		return "", nil, err
+	}
 	if hwhStr != "" {
 		b.SqlStr = fmt.Sprintf("%s having %s", b.SqlStr, hwhStr)
 	}
@@ -624,12 +994,18 @@ func (b *sqlBuilder) BuildSelect() (string, []any, error) {
 	}
 
 	// ORDER BY
-	if ob := b.buildOrderBy(); ob != "" {
+	ob := b.buildOrderBy()
+	lm := b.buildLimit()
+	// OFFSET ... FETCH 必须带 ORDER BY
+	if ob == "" && lm != "" && d.Supports(FeatureOffsetFetch) {
+		ob = "order by (select null)"
+	}
+	if ob != "" {
 		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, ob)
 	}
 
 	// LIMIT
-	if lm := b.buildLimit(); lm != "" {
+	if lm != "" {
 		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, lm)
 	}
 
@@ -643,9 +1019,6 @@ func (b *sqlBuilder) BuildSelect() (string, []any, error) {
 		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, un)
 	}
 
-	if b.debugSql {
-		fmt.Println(b.SqlStr)
-	}
 	// 检查子构建器（CTE/JOIN/UNION）是否设置了错误
 	if b.err != nil {
 		return "", nil, b.err
@@ -653,6 +1026,32 @@ func (b *sqlBuilder) BuildSelect() (string, []any, error) {
 	return b.SqlStr, b.fieldValue, nil
 }
 
+// checkSelectFeatures 校验 SELECT 用到的方言相关特性
+func (b *sqlBuilder) checkSelectFeatures() error {
+	if len(b.sqlHints) > 0 {
+		if err := b.require(FeatureSqlHint, strings.Join(b.sqlHints, " ")); err != nil {
+			return err
+		}
+	}
+	if b.indexHintType != "" {
+		if err := b.require(FeatureIndexHint, b.indexHintType+" index"); err != nil {
+			return err
+		}
+	}
+	if b.withRollup {
+		if err := b.require(FeatureWithRollup, "with rollup"); err != nil {
+			return err
+		}
+	}
+	switch b.lockClause {
+	case "for update":
+		return b.require(FeatureForUpdate, b.lockClause)
+	case "lock in share mode":
+		return b.require(FeatureLockInShareMode, b.lockClause)
+	}
+	return nil
+}
+
 // buildSelectPrefix 构建 SELECT 前缀（含 DISTINCT 和 SQL hints）
 func (b *sqlBuilder) buildSelectPrefix() string {
 	var sb strings.Builder
@@ -671,28 +1070,29 @@ func (b *sqlBuilder) buildCTE() string {
 	if len(b.ctes) == 0 {
 		return ""
 	}
+	d := b.getDialect()
 	var sb strings.Builder
 	sb.WriteString("with ")
-	if b.recursive {
+	if b.recursive && d.Supports(FeatureRecursiveKeyword) {
 		sb.WriteString("recursive ")
 	}
 	for i, cte := range b.ctes {
 		if i > 0 {
 			sb.WriteString(", ")
 		}
-		sb.WriteString(fmt.Sprintf("`%s`", cte.name))
+		sb.WriteString(d.Quote(cte.name))
 		if len(cte.columns) > 0 {
 			cols := make([]string, len(cte.columns))
 			for j, col := range cte.columns {
-				cols[j] = fmt.Sprintf("`%s`", col)
+				cols[j] = d.Quote(col)
 			}
 			sb.WriteString(fmt.Sprintf(" (%s)", strings.Join(cols, ", ")))
 		}
-		cteSql, cteArgs, err := cte.definition.BuildSelect()
-			if err != nil {
-				b.err = err
-				return ""
-			}
+		cteSql, cteArgs, err := cte.definition.subquery(d)
+		if err != nil {
+			b.err = err
+			return ""
+		}
 		b.fieldValue = append(b.fieldValue, cteArgs...)
 		sb.WriteString(fmt.Sprintf(" as (%s)", cteSql))
 	}
@@ -718,7 +1118,7 @@ func (b *sqlBuilder) buildSelectFields() (string, error) {
 	}
 	fields := fieldsBuilder.String()
 	if fields == "" {
-		fields = fmt.Sprintf("`%s`.*", b.alias)
+		fields = fmt.Sprintf("%s.*", b.quote(b.alias))
 	}
 	return fields, nil
 }
@@ -755,7 +1155,7 @@ func (b *sqlBuilder) formatSelectField(v any) (string, error) {
 		if len(val.fields) == 0 || len(val.fields) > 1 {
 			return "", errors.New("子查询仅需要一个字段")
 		}
-		childQuery, data, err := val.BuildSelect()
+		childQuery, data, err := val.subquery(b.getDialect())
 		if err != nil {
 			return "", err
 		}
@@ -764,7 +1164,7 @@ func (b *sqlBuilder) formatSelectField(v any) (string, error) {
 		if fstr == "" {
 			return "", errors.New("子查询字段无法提取别名")
 		}
-		return fmt.Sprintf("(%s) as `%s`", childQuery, fstr), nil
+		return fmt.Sprintf("(%s) as %s", childQuery, b.quote(fstr)), nil
 	case *funCarrier:
 		if val.Fn != "" {
 			var fnpBuilder strings.Builder
@@ -774,7 +1174,7 @@ func (b *sqlBuilder) formatSelectField(v any) (string, error) {
 				}
 				fnpBuilder.WriteString(fmt.Sprint(vv))
 			}
-			return fmt.Sprintf("%s(%s) as `%s`", val.Fn, fnpBuilder.String(), val.Alias), nil
+			return fmt.Sprintf("%s(%s) as %s", val.Fn, fnpBuilder.String(), b.quote(val.Alias)), nil
 		}
 	case *winCarrier:
 		return b.formatWinField(val), nil
@@ -784,14 +1184,18 @@ func (b *sqlBuilder) formatSelectField(v any) (string, error) {
 		if val.TableAlias == "" && val.Field == "" && val.FieldAlias == "" {
 			return "", nil
 		}
+		col := b.quote(val.Field)
 		if val.TableAlias != "" {
-			return strings.TrimSpace(fmt.Sprintf("`%s`.`%s` %s", val.TableAlias, val.Field, val.FieldAlias)), nil
+			col = b.quoteCol(val.TableAlias, val.Field)
+		} else if val.Field == "" {
+			return "", nil
 		}
-		if val.Field != "" {
-			return strings.TrimSpace(fmt.Sprintf("`%s` %s", val.Field, val.FieldAlias)), nil
+		if val.FieldAlias != "" {
+			return fmt.Sprintf("%s as %s", col, b.quote(val.FieldAlias)), nil
 		}
+		return col, nil
 	case string:
-		// 通配符 * 不加反引号，否则 MySQL 会把它当成字面列名
+		// 通配符 * 不加引号，否则数据库会把它当成字面列名
 		if val == "*" {
 			return fmt.Sprintf("%s.*", b.alias), nil
 		}
@@ -801,28 +1205,37 @@ func (b *sqlBuilder) formatSelectField(v any) (string, error) {
 				return "", errors.New("错误的查询字段格式，期望 'table.field'")
 			}
 			if arr[1] == "*" {
-				return fmt.Sprintf("`%s`.*", arr[0]), nil
+				return fmt.Sprintf("%s.*", b.quote(arr[0])), nil
 			}
-			return fmt.Sprintf("`%s`.`%s`", arr[0], arr[1]), nil
+			return b.quoteCol(arr[0], arr[1]), nil
 		}
-		return fmt.Sprintf("`%s`.`%s`", b.alias, val), nil
+		return b.quoteCol(b.alias, val), nil
 	default:
 		return "", errors.New("不支持的查询字段类型")
 	}
 	return "", nil
 }
 
-// buildFromClause 构建 FROM 子句
-func (b *sqlBuilder) buildFromClause() string {
-	target := fmt.Sprintf("`%s`", b.tableName)
-	if b.childQuery != "" {
-		target = fmt.Sprintf("(%s)", b.childQuery)
+// buildFromClause 构建 FROM 子句，FROM 子查询的参数在此时加入
+func (b *sqlBuilder) buildFromClause() (string, error) {
+	target := b.quote(b.tableName)
+	if b.fromQuery != nil {
+		childQuery, data, err := b.fromQuery.subquery(b.getDialect())
+		if err != nil {
+			return "", err
+		}
+		b.fieldValue = append(b.fieldValue, data...)
+		target = fmt.Sprintf("(%s)", childQuery)
 	}
-	result := fmt.Sprintf("from %s as `%s`", target, b.alias)
-	if b.indexHint != "" {
-		result += " " + b.indexHint
+	result := fmt.Sprintf("from %s as %s", target, b.quote(b.alias))
+	if b.indexHintType != "" {
+		quoted := make([]string, len(b.indexHints))
+		for i, idx := range b.indexHints {
+			quoted[i] = b.quote(idx)
+		}
+		result += fmt.Sprintf(" %s index (%s)", b.indexHintType, strings.Join(quoted, ", "))
 	}
-	return result
+	return result, nil
 }
 
 // buildGroupBy 构建 GROUP BY 子句
@@ -838,9 +1251,9 @@ func (b *sqlBuilder) buildGroupBy() string {
 		}
 		if strings.Contains(v, ".") {
 			parts := strings.Split(v, ".")
-			groupBuilder.WriteString(fmt.Sprintf("`%s`.`%s`", parts[0], parts[1]))
+			groupBuilder.WriteString(b.quoteCol(parts[0], parts[1]))
 		} else {
-			groupBuilder.WriteString(fmt.Sprintf("`%s`.`%s`", b.alias, v))
+			groupBuilder.WriteString(b.quoteCol(b.alias, v))
 		}
 	}
 	if b.withRollup {
@@ -865,9 +1278,9 @@ func (b *sqlBuilder) buildOrderBy() string {
 			orderBuilder.WriteByte(',')
 		}
 		if len(v) == 2 {
-			orderBuilder.WriteString(fmt.Sprintf("`%s`.`%s` %s", b.alias, v[0], v[1]))
+			orderBuilder.WriteString(fmt.Sprintf("%s %s", b.quoteCol(b.alias, fmt.Sprint(v[0])), v[1]))
 		} else if len(v) == 3 {
-			orderBuilder.WriteString(fmt.Sprintf("`%s`.`%s` %s", v[2], v[0], v[1]))
+			orderBuilder.WriteString(fmt.Sprintf("%s %s", b.quoteCol(fmt.Sprint(v[2]), fmt.Sprint(v[0])), v[1]))
 		}
 		added++
 	}
@@ -879,13 +1292,10 @@ func (b *sqlBuilder) buildOrderBy() string {
 
 // buildLimit 构建 LIMIT 子句
 func (b *sqlBuilder) buildLimit() string {
-	if b.offset >= 0 && b.pageSize > 0 {
-		return fmt.Sprintf("limit %d,%d", b.offset, b.pageSize)
-	}
-	if b.offset < 0 && b.pageSize > 0 {
-		return fmt.Sprintf("limit %d", b.pageSize)
+	if b.pageSize <= 0 {
+		return ""
 	}
-	return ""
+	return b.getDialect().Limit(b.offset, b.pageSize)
 }
 
 // buildUnions 构建 UNION 子句
@@ -893,15 +1303,20 @@ func (b *sqlBuilder) buildUnions() string {
 	if len(b.unions) == 0 {
 		return ""
 	}
+	d := b.getDialect()
 	var parts []string
 	for _, u := range b.unions {
-		subSql, subArgs, err := u.builder.BuildSelect()
-			if err != nil {
-				b.err = err
-				return ""
-			}
+		subSql, subArgs, err := u.builder.subquery(d)
+		if err != nil {
+			b.err = err
+			return ""
+		}
 		b.fieldValue = append(b.fieldValue, subArgs...)
-		parts = append(parts, fmt.Sprintf("%s (%s)", u.typ, subSql))
+		if d.Supports(FeatureUnionParens) {
+			parts = append(parts, fmt.Sprintf("%s (%s)", u.typ, subSql))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %s", u.typ, subSql))
+		}
 	}
 	return strings.Join(parts, " ")
 }
@@ -929,7 +1344,7 @@ func (b *sqlBuilder) formatWinField(val *winCarrier) string {
 			if i > 0 {
 				overBuilder.WriteString(", ")
 			}
-			overBuilder.WriteString(fmt.Sprintf("`%s`.`%s`", b.alias, f))
+			overBuilder.WriteString(b.quoteCol(b.alias, f))
 		}
 		needSpace = true
 	}
@@ -946,15 +1361,15 @@ func (b *sqlBuilder) formatWinField(val *winCarrier) string {
 				overBuilder.WriteString(", ")
 			}
 			if len(v) == 2 {
-				overBuilder.WriteString(fmt.Sprintf("`%s`.`%s` %s", b.alias, v[0], v[1]))
+				overBuilder.WriteString(fmt.Sprintf("%s %s", b.quoteCol(b.alias, fmt.Sprint(v[0])), v[1]))
 			} else if len(v) == 3 {
-				overBuilder.WriteString(fmt.Sprintf("`%s`.`%s` %s", v[2], v[0], v[1]))
+				overBuilder.WriteString(fmt.Sprintf("%s %s", b.quoteCol(fmt.Sprint(v[2]), fmt.Sprint(v[0])), v[1]))
 			}
 		}
 	}
 	overBuilder.WriteByte(')')
 
-	return fmt.Sprintf("%s over %s as `%s`", fnCall, overBuilder.String(), val.Alias)
+	return fmt.Sprintf("%s over %s as %s", fnCall, overBuilder.String(), b.quote(val.Alias))
 }
 
 // formatCaseField 格式化 CASE WHEN 字段
@@ -962,7 +1377,7 @@ func (b *sqlBuilder) formatCaseField(val *caseCarrier) (string, error) {
 	var sb strings.Builder
 	sb.WriteString("case ")
 	if val.CaseField != "" {
-		sb.WriteString(fmt.Sprintf("`%s`.`%s` ", b.alias, val.CaseField))
+		sb.WriteString(b.quoteCol(b.alias, val.CaseField) + " ")
 	}
 	for _, w := range val.Whens {
 		switch wt := w.When.(type) {
@@ -972,10 +1387,10 @@ func (b *sqlBuilder) formatCaseField(val *caseCarrier) (string, error) {
 			}
 			sb.WriteString(fmt.Sprintf("when %s then ? ", wt))
 		case *sqlBuilder:
-			subSql, subArgs, err := wt.BuildSelect()
+			subSql, subArgs, err := wt.subquery(b.getDialect())
 			if err != nil {
-			return "", err
-				}
+				return "", err
+			}
 			b.fieldValue = append(b.fieldValue, subArgs...)
 			sb.WriteString(fmt.Sprintf("when (%s) then ? ", subSql))
 		default:
@@ -994,12 +1409,17 @@ func (b *sqlBuilder) formatCaseField(val *caseCarrier) (string, error) {
 		sb.WriteString("else ? ")
 		b.fieldValue = append(b.fieldValue, val.ElseVal)
 	}
-	sb.WriteString(fmt.Sprintf("end as `%s`", val.Alias))
+	sb.WriteString(fmt.Sprintf("end as %s", b.quote(val.Alias)))
 	return sb.String(), nil
 }
 
 // BuildMapNamedInsert 使用 map 构建插入 SQL，使用命名参数（:key）
-func (b *sqlBuilder) BuildMapNamedInsert(option map[string]any) (string, map[string]any) {
+func (b *sqlBuilder) BuildMapNamedInsert(option map[string]any) (sqlStr string, named map[string]any) {
+	defer b.observe("BuildMapNamedInsert")(&sqlStr, nil, &b.err)
+	if err := b.checkErr(); err != nil {
+		b.err = err
+		return "", nil
+	}
 	if len(option) == 0 {
 		return "", nil
 	}
@@ -1009,45 +1429,68 @@ func (b *sqlBuilder) BuildMapNamedInsert(option map[string]any) (string, map[str
 	}
 	keysArr := []string{}
 	valsArr := []string{}
-	for k := range option {
-		keysArr = append(keysArr, fmt.Sprintf("`%s`", k))
+	for _, k := range b.orderedKeys(option) {
+		keysArr = append(keysArr, b.quote(k))
 		valsArr = append(valsArr, fmt.Sprintf(":%s", k))
 	}
-	sqlStr := fmt.Sprintf("insert into `%s` (%s) values (%s)", b.tableName, strings.Join(keysArr, ","), strings.Join(valsArr, ","))
+	sqlStr = fmt.Sprintf("insert into %s (%s) values (%s)", b.quote(b.tableName), strings.Join(keysArr, ","), strings.Join(valsArr, ","))
 	return sqlStr, option
 }
 
 // BuildMapInsert 使用 map 构建插入 SQL，使用 ? 占位符
-func (b *sqlBuilder) BuildMapInsert(option map[string]any) (string, []any) {
+func (b *sqlBuilder) BuildMapInsert(option map[string]any) (sqlStr string, args []any) {
+	defer b.observe("BuildMapInsert")(&sqlStr, &args, &b.err)
 	return b.buildMapInsert("insert", option)
 }
 
 // BuildSliceMapInsert 使用 map 切片构建批量插入 SQL，使用 ? 占位符
-func (b *sqlBuilder) BuildSliceMapInsert(option []map[string]any) (string, []any) {
+func (b *sqlBuilder) BuildSliceMapInsert(option []map[string]any) (sqlStr string, args []any) {
+	defer b.observe("BuildSliceMapInsert")(&sqlStr, &args, &b.err)
 	return b.buildSliceMapInsert("insert", option)
 }
 
 // BuildSliceMapNamedInsert 使用 map 切片构建批量插入 SQL，使用命名参数（:key）
-func (b *sqlBuilder) BuildSliceMapNamedInsert(option []map[string]any) (string, []map[string]any) {
+func (b *sqlBuilder) BuildSliceMapNamedInsert(option []map[string]any) (sqlStr string, named []map[string]any) {
+	defer b.observe("BuildSliceMapNamedInsert")(&sqlStr, nil, &b.err)
+	if err := b.checkErr(); err != nil {
+		b.err = err
+		return "", nil
+	}
 	if len(option) == 0 {
 		return "", nil
 	}
-	if err := validateMapKeys(option[0]); err != nil {
+	keys, err := b.rowKeys(option)
+	if err != nil {
 		b.err = err
 		return "", nil
 	}
 	keysArr := []string{}
 	valsArr := []string{}
-	for k := range option[0] {
-		keysArr = append(keysArr, fmt.Sprintf("`%s`", k))
+	for _, k := range keys {
+		keysArr = append(keysArr, b.quote(k))
 		valsArr = append(valsArr, fmt.Sprintf(":%s", k))
 	}
-	sqlStr := fmt.Sprintf("insert into `%s` (%s) values (%s)", b.tableName, strings.Join(keysArr, ","), strings.Join(valsArr, ","))
+	sqlStr = fmt.Sprintf("insert into %s (%s) values (%s)", b.quote(b.tableName), strings.Join(keysArr, ","), strings.Join(valsArr, ","))
+	if b.rowKeysMode == rowKeysUnion {
+		// 补齐缺失列的默认值，不修改调用方传入的 map
+		rows := make([]map[string]any, len(option))
+		for i, row := range option {
+			rows[i] = make(map[string]any, len(keys))
+			for _, k := range keys {
+				rows[i][k] = b.rowValue(row, k)
+			}
+		}
+		return sqlStr, rows
+	}
 	return sqlStr, option
 }
 
 // BuildStructNamedInsert 使用结构体构建插入 SQL，使用命名参数（:tag），通过 db tag 映射字段
-func (b *sqlBuilder) BuildStructNamedInsert(entity any) (string, error) {
+func (b *sqlBuilder) BuildStructNamedInsert(entity any) (sqlStr string, err error) {
+	defer b.observe("BuildStructNamedInsert")(&sqlStr, nil, &err)
+	if err := b.checkErr(); err != nil {
+		return "", err
+	}
 	if b.dbTag == "" {
 		b.dbTag = "db"
 	}
@@ -1068,7 +1511,7 @@ func (b *sqlBuilder) BuildStructNamedInsert(entity any) (string, error) {
 	if len(fields) == 0 {
 		return "", errors.New("没有可插入的字段")
 	}
-	return fmt.Sprintf("insert into `%s` (%s) values(%s)", b.tableName, strings.Join(fields, ","), strings.Join(nameFields, ",")), nil
+	return fmt.Sprintf("insert into %s (%s) values(%s)", b.quote(b.tableName), strings.Join(fields, ","), strings.Join(nameFields, ",")), nil
 }
 
 func (b *sqlBuilder) recursionStructNamedEmbed(elemVal reflect.Value, fields *[]string, nameFields *[]string) {
@@ -1078,24 +1521,29 @@ func (b *sqlBuilder) recursionStructNamedEmbed(elemVal reflect.Value, fields *[]
 			b.recursionStructNamedEmbed(elemVal.Field(i), fields, nameFields)
 		}
 		field := typ.Field(i)
-		dbTag := field.Tag.Get(b.dbTag)
-		if dbTag == "" || dbTag == "-" {
+		tag := parseFieldTag(field.Tag.Get(b.dbTag))
+		if tag.ignored() {
 			continue
 		}
+		dbTag := tag.name
 		fieldVal := elemVal.Field(i)
 		if !fieldVal.CanInterface() {
 			continue
 		}
-		if ok := b.shouldSkipField(fieldVal, dbTag); ok {
+		if ok := b.skipStructField(fieldVal, tag, structInsert); ok {
 			continue
 		}
-		*fields = append(*fields, fmt.Sprintf("`%s`", dbTag))
+		*fields = append(*fields, dbTag)
 		*nameFields = append(*nameFields, fmt.Sprintf(":%s", dbTag))
 	}
 }
 
 // BuildStructInsert 使用结构体构建插入 SQL，使用 ? 占位符，通过 db tag 映射字段
-func (b *sqlBuilder) BuildStructInsert(entity any) (string, []any, error) {
+func (b *sqlBuilder) BuildStructInsert(entity any) (sqlStr string, args []any, err error) {
+	defer b.observe("BuildStructInsert")(&sqlStr, &args, &err)
+	if err := b.checkErr(); err != nil {
+		return "", nil, err
+	}
 	if b.dbTag == "" {
 		b.dbTag = "db"
 	}
@@ -1118,7 +1566,14 @@ func (b *sqlBuilder) BuildStructInsert(entity any) (string, []any, error) {
 	if len(fields) == 0 {
 		return "", nil, errors.New("没有可插入的字段")
 	}
-	return fmt.Sprintf("insert into `%s` (%s) values(%s)", b.tableName, strings.Join(fields, ","), placeHolder), valsArr, nil
+	insertSql := fmt.Sprintf("insert into %s (%s) values(%s)", b.quote(b.tableName), strings.Join(b.quoteAll(fields), ","), placeHolder)
+
+	// ON DUPLICATE KEY UPDATE / ON CONFLICT
+	suffix, suffixArgs, err := b.insertSuffix(fields)
+	if err != nil {
+		return "", nil, err
+	}
+	return rebind(b.getDialect(), insertSql+suffix), append(valsArr, suffixArgs...), nil
 }
 
 func (b *sqlBuilder) recursionStructEmbed(elemVal reflect.Value, fields *[]string, valsArr *[]any, fieldLen *int) {
@@ -1128,26 +1583,31 @@ func (b *sqlBuilder) recursionStructEmbed(elemVal reflect.Value, fields *[]strin
 			b.recursionStructEmbed(elemVal.Field(i), fields, valsArr, fieldLen)
 		}
 		field := typ.Field(i)
-		dbTag := field.Tag.Get(b.dbTag)
-		if dbTag == "" || dbTag == "-" {
+		tag := parseFieldTag(field.Tag.Get(b.dbTag))
+		if tag.ignored() {
 			continue
 		}
+		dbTag := tag.name
 		fieldVal := elemVal.Field(i)
 		if !fieldVal.CanInterface() {
 			continue
 		}
-		if ok := b.shouldSkipField(fieldVal, dbTag); ok {
+		if ok := b.skipStructField(fieldVal, tag, structInsert); ok {
 			continue
 		}
 
-		*fields = append(*fields, fmt.Sprintf("`%s`", dbTag))
-		*valsArr = append(*valsArr, fieldVal.Interface())
+		*fields = append(*fields, dbTag)
+		*valsArr = append(*valsArr, structFieldValue(fieldVal))
 		*fieldLen += 1
 	}
 }
 
 // BuildSliceStructInsert 使用结构体切片构建批量插入 SQL，使用 ? 占位符，通过 db tag 映射字段
-func (b *sqlBuilder) BuildSliceStructInsert(entity any) (string, []any, error) {
+func (b *sqlBuilder) BuildSliceStructInsert(entity any) (sqlStr string, args []any, err error) {
+	defer b.observe("BuildSliceStructInsert")(&sqlStr, &args, &err)
+	if err := b.checkErr(); err != nil {
+		return "", nil, err
+	}
 	if b.dbTag == "" {
 		b.dbTag = "db"
 	}
@@ -1161,60 +1621,79 @@ func (b *sqlBuilder) BuildSliceStructInsert(entity any) (string, []any, error) {
 		return "", nil, errors.New("参数不是指针切片类型")
 	}
 
-	fieldValueArr := []any{}
-	keysArr := []string{}
-	sqlValueArr := []string{}
+	keys, rows, err := b.structSliceRows(elemVal)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(keys) == 0 {
+		return "", nil, errors.New("没有可插入的字段")
+	}
+	return b.renderSliceMapInsert("insert", keys, rows)
+}
 
-	sliceLenth := elemVal.Len()
-	for i := 0; i < sliceLenth; i++ {
+// structSliceRows 将结构体切片的每个元素转换为 列 => 值，并计算所有行共用的列
+// 列为各行未被跳过的字段的并集，按字段声明顺序排列；某行跳过的列（如零值）绑定该字段的实际值，
+// 与单独插入时由数据库取默认值不同，但不会写入 NULL
+func (b *sqlBuilder) structSliceRows(elemVal reflect.Value) ([]string, []map[string]any, error) {
+	var cols []string
+	used := make(map[string]bool)
+	rows := make([]map[string]any, elemVal.Len())
+	for i := range rows {
 		item := elemVal.Index(i)
 		if item.Kind() != reflect.Struct {
-			return "", nil, errors.New("切片中的元素不是结构体类型")
+			return nil, nil, errors.New("切片中的元素不是结构体类型")
+		}
+		rows[i] = make(map[string]any)
+		if i == 0 {
+			b.recursionSliceStructEmbed(item, &cols, rows[i], used)
+		} else {
+			b.recursionSliceStructEmbed(item, nil, rows[i], used)
 		}
-
-		placeholderArr := []string{}
-		// itemType := item.Type()
-		b.recursionSliceStructEmbed(item, i, &keysArr, &fieldValueArr, &placeholderArr)
-
-		sqlValueArr = append(sqlValueArr, fmt.Sprintf("(%s)", strings.Join(placeholderArr, ",")))
-
 	}
-	if len(keysArr) == 0 {
-		return "", nil, errors.New("没有可插入的字段")
+	var keys []string
+	for _, k := range cols {
+		if used[k] {
+			keys = append(keys, k)
+		}
 	}
-	insertSql := fmt.Sprintf("insert into `%s` (%s) values %s", b.tableName, strings.Join(keysArr, ","), strings.Join(sqlValueArr, ","))
-
-	return insertSql, fieldValueArr, nil
+	return keys, rows, nil
 }
 
-func (b *sqlBuilder) recursionSliceStructEmbed(elemVal reflect.Value, i int, keysArr *[]string, fieldValueArr *[]any, placeholderArr *[]string) {
+// recursionSliceStructEmbed 将结构体可插入字段的值写入 row，未被跳过的列记入 used，
+// cols 不为 nil 时按声明顺序记录所有可插入的列
+func (b *sqlBuilder) recursionSliceStructEmbed(elemVal reflect.Value, cols *[]string, row map[string]any, used map[string]bool) {
 	itemType := elemVal.Type()
 	for j := 0; j < elemVal.NumField(); j++ {
 		if itemType.Field(j).Anonymous && itemType.Field(j).Type.Kind() == reflect.Struct {
-			b.recursionSliceStructEmbed(elemVal.Field(j), i, keysArr, fieldValueArr, placeholderArr)
+			b.recursionSliceStructEmbed(elemVal.Field(j), cols, row, used)
 		}
 		field := itemType.Field(j)
-		dbTag := field.Tag.Get(b.dbTag)
-		if dbTag == "" || dbTag == "-" {
+		tag := parseFieldTag(field.Tag.Get(b.dbTag))
+		if tag.ignored() {
 			continue
 		}
+		dbTag := tag.name
 		fieldVal := elemVal.Field(j)
 		if !fieldVal.CanInterface() {
 			continue
 		}
-		if ok := b.shouldSkipField(fieldVal, dbTag); ok {
-			continue
+		if cols != nil {
+			*cols = append(*cols, dbTag)
 		}
-		if i == 0 {
-			*keysArr = append(*keysArr, fmt.Sprintf("`%s`", dbTag))
+		row[dbTag] = structFieldValue(fieldVal)
+		if ok := b.skipStructField(fieldVal, tag, structInsert); ok {
+			continue
 		}
-		*fieldValueArr = append(*fieldValueArr, fieldVal.Interface())
-		*placeholderArr = append(*placeholderArr, "?")
+		used[dbTag] = true
 	}
 }
 
 // BuildSliceStructNamedInsert 使用结构体切片构建批量插入 SQL，使用命名参数（:tag），通过 db tag 映射字段
-func (b *sqlBuilder) BuildSliceStructNamedInsert(entity any) (string, error) {
+func (b *sqlBuilder) BuildSliceStructNamedInsert(entity any) (sqlStr string, err error) {
+	defer b.observe("BuildSliceStructNamedInsert")(&sqlStr, nil, &err)
+	if err := b.checkErr(); err != nil {
+		return "", err
+	}
 	if b.dbTag == "" {
 		b.dbTag = "db"
 	}
@@ -1249,7 +1728,7 @@ func (b *sqlBuilder) BuildSliceStructNamedInsert(entity any) (string, error) {
 		return "", errors.New("没有可插入的字段")
 	}
 	namedStr := fmt.Sprintf("(%s)", strings.Join(placeholderArr, ","))
-	insertSql := fmt.Sprintf("insert into `%s` (%s) values %s", b.tableName, strings.Join(keysArr, ","), namedStr)
+	insertSql := fmt.Sprintf("insert into %s (%s) values %s", b.quote(b.tableName), strings.Join(keysArr, ","), namedStr)
 
 	return insertSql, nil
 }
@@ -1261,24 +1740,30 @@ func (b *sqlBuilder) recursionSliceStructNamedEmbed(firstItem reflect.Value, key
 			b.recursionSliceStructNamedEmbed(firstItem.Field(j), keysArr, placeholderArr)
 		}
 		field := itemType.Field(j)
-		dbTag := field.Tag.Get(b.dbTag)
-		if dbTag == "" || dbTag == "-" {
+		tag := parseFieldTag(field.Tag.Get(b.dbTag))
+		if tag.ignored() {
 			continue
 		}
+		dbTag := tag.name
 		fieldVal := firstItem.Field(j)
 		if !fieldVal.CanInterface() {
 			continue
 		}
-		if ok := b.shouldSkipField(fieldVal, dbTag); ok {
+		if ok := b.skipStructField(fieldVal, tag, structInsert); ok {
 			continue
 		}
-		*keysArr = append(*keysArr, fmt.Sprintf("`%s`", dbTag))
+		*keysArr = append(*keysArr, b.quote(dbTag))
 		*placeholderArr = append(*placeholderArr, fmt.Sprintf(":%s", dbTag))
 	}
 }
 
 // BuildMapUpdate 使用 map 构建更新 SQL，使用 ? 占位符，option 中值为 []any{字段名, 运算符, 值} 时表示字段运算
-func (b *sqlBuilder) BuildMapUpdate(option map[string]any) (string, []any, error) {
+func (b *sqlBuilder) BuildMapUpdate(option map[string]any) (sqlStr string, args []any, err error) {
+	defer b.observe("BuildMapUpdate")(&sqlStr, &args, &err)
+	b = b.prepare()
+	if err := b.checkErr(); err != nil {
+		return "", nil, err
+	}
 	if err := validateMapKeys(option); err != nil {
 		return "", nil, err
 	}
@@ -1286,55 +1771,72 @@ func (b *sqlBuilder) BuildMapUpdate(option map[string]any) (string, []any, error
 	if b.alias != "" {
 		tableName = b.alias
 	}
-	var valsBuilder strings.Builder
-	for k, v := range option {
-		if valsBuilder.Len() > 0 {
-			valsBuilder.WriteByte(',')
+	var setStr string
+	if b.versionField != "" {
+		current, ok := option[b.versionField]
+		if !ok {
+			return "", nil, fmt.Errorf("乐观锁需要在 option 中提供版本号字段 %s 的当前值", b.versionField)
+		}
+		b.version = &versionLock{column: b.versionField, current: current}
+		rest := make(map[string]any, len(option))
+		for k, v := range option {
+			if k != b.versionField {
+				rest[k] = v
+			}
 		}
-		switch val := v.(type) {
-		case string:
-			b.fieldValue = append(b.fieldValue, val)
-			valsBuilder.WriteString(fmt.Sprintf("`%s`.`%s` = ?", tableName, k))
-		case int, int8, int32, int16, int64, uint, uint8, uint16, uint32, uint64,
-			float32, float64:
-			b.fieldValue = append(b.fieldValue, val)
-			valsBuilder.WriteString(fmt.Sprintf("`%s`.`%s` = ?", tableName, k))
-		case time.Time:
-			b.fieldValue = append(b.fieldValue, val)
-			valsBuilder.WriteString(fmt.Sprintf("`%s`.`%s` = ?", tableName, k))
-		case []any:
-			if len(val) < 3 {
-				return "", nil, fmt.Errorf("字段 %s 的运算表达式格式错误，需要 []any{字段名, 运算符, 值}", k)
+		if len(rest) > 0 {
+			if setStr, err = b.buildMapSet(rest, tableName, b.setCol); err != nil {
+				return "", nil, err
 			}
-			b.fieldValue = append(b.fieldValue, val[2])
-			valsBuilder.WriteString(fmt.Sprintf("`%s`.`%s` = `%s`.`%s`%s?", tableName, k, tableName, val[0], val[1]))
+			setStr += ","
 		}
+		setStr += b.versionSet(tableName, b.setCol)
+	} else if setStr, err = b.buildMapSet(option, tableName, b.setCol); err != nil {
+		return "", nil, err
 	}
-	b.SqlStr = fmt.Sprintf("update `%s` as `%s` set %s", b.tableName, tableName, valsBuilder.String())
+	b.SqlStr = b.buildUpdateHead(setStr)
 
-	whStr, whArgs := b.whr.ParseWhere()
+	whStr, whArgs, err := b.buildWhere()
+	if err != nil {
+		return "", nil, err
+	}
 	if whStr != "" {
-		b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, whStr)
+		b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, appendConds(whStr, b.extraConds()...))
 	} else {
 		return "", nil, errors.New("更新操作必须有一个条件")
 	}
 	if len(whArgs) > 0 {
 		b.fieldValue = append(b.fieldValue, whArgs...)
 	}
+	b.fieldValue = append(b.fieldValue, b.extraArgs()...)
 
 	// ORDER BY and LIMIT for UPDATE
-	if ob := b.buildOrderBy(); ob != "" {
-		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, ob)
+	sqlStr, err = b.appendOrderLimit(b.SqlStr)
+	if err != nil {
+		return "", nil, err
 	}
-	if lm := b.buildLimit(); lm != "" {
-		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, lm)
+	b.SqlStr = sqlStr
+
+	// RETURNING
+	if b.SqlStr, err = b.appendReturning(b.SqlStr, FeatureUpdateReturning); err != nil {
+		return "", nil, err
 	}
 
-	return b.SqlStr, b.fieldValue, nil
+	return rebind(b.getDialect(), b.SqlStr), b.fieldValue, nil
 }
 
 // BuildStructUpdate 使用结构体构建更新 SQL，使用 ? 占位符，通过 db tag 映射字段
-func (b *sqlBuilder) BuildStructUpdate(entity any) (string, []any, error) {
+func (b *sqlBuilder) BuildStructUpdate(entity any) (sqlStr string, args []any, err error) {
+	defer b.observe("BuildStructUpdate")(&sqlStr, &args, &err)
+	return b.buildStructUpdate(entity, structUpdate)
+}
+
+// buildStructUpdate 构建结构体更新 SQL，op 为 structUpdate 或 structUpdateByPK
+func (b *sqlBuilder) buildStructUpdate(entity any, op int) (string, []any, error) {
+	b = b.prepare()
+	if err := b.checkErr(); err != nil {
+		return "", nil, err
+	}
 	if b.dbTag == "" {
 		b.dbTag = "db"
 	}
@@ -1350,81 +1852,116 @@ func (b *sqlBuilder) BuildStructUpdate(entity any) (string, []any, error) {
 
 	var setStr string
 	var fieldArr []string
-	if err := b.recursionEmbedStruct(reflectVal, &fieldArr, tableName); err != nil {
+	if err := b.recursionEmbedStruct(reflectVal, &fieldArr, tableName, op); err != nil {
 		return "", nil, err
 	}
+	if b.versionField != "" && b.version == nil {
+		return "", nil, fmt.Errorf("结构体 %s 没有版本号字段 %s", reflectVal.Type(), b.versionField)
+	}
+	if b.version != nil {
+		fieldArr = append(fieldArr, b.versionSet(tableName, b.setCol))
+	}
 
 	setStr = strings.Join(fieldArr, ",")
-	b.SqlStr = fmt.Sprintf("update `%s` as `%s` set %s", b.tableName, tableName, setStr)
+	b.SqlStr = b.buildUpdateHead(setStr)
 
-	whStr, whArgs := b.whr.ParseWhere()
+	whStr, whArgs, err := b.buildWhere()
+	if err != nil {
+		return "", nil, err
+	}
 	if whStr != "" {
-		b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, whStr)
+		b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, appendConds(whStr, b.extraConds()...))
 	} else {
 		return "", nil, errors.New("更新操作必须有一个条件")
 	}
 	if len(whArgs) > 0 {
 		b.fieldValue = append(b.fieldValue, whArgs...)
 	}
+	b.fieldValue = append(b.fieldValue, b.extraArgs()...)
 
 	// ORDER BY and LIMIT for UPDATE
-	if ob := b.buildOrderBy(); ob != "" {
-		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, ob)
+	sqlStr, err := b.appendOrderLimit(b.SqlStr)
+	if err != nil {
+		return "", nil, err
 	}
-	if lm := b.buildLimit(); lm != "" {
-		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, lm)
+	b.SqlStr = sqlStr
+
+	// RETURNING
+	if b.SqlStr, err = b.appendReturning(b.SqlStr, FeatureUpdateReturning); err != nil {
+		return "", nil, err
 	}
 
-	return b.SqlStr, b.fieldValue, nil
+	return rebind(b.getDialect(), b.SqlStr), b.fieldValue, nil
 }
 
 // recursionEmbedStruct 递归解析嵌套结构体的 db tag 字段用于更新
 
-func (b *sqlBuilder) recursionEmbedStruct(reflectVal reflect.Value, fieldArr *[]string, tableName string) error {
+func (b *sqlBuilder) recursionEmbedStruct(reflectVal reflect.Value, fieldArr *[]string, tableName string, op int) error {
 	numFields := reflectVal.NumField()
 	typElem := reflectVal.Type()
 	for i := 0; i < numFields; i++ {
 		field := typElem.Field(i)
 		if field.Anonymous && field.Type.Kind() == reflect.Struct {
-			b.recursionEmbedStruct(reflectVal.Field(i), fieldArr, tableName)
+			if err := b.recursionEmbedStruct(reflectVal.Field(i), fieldArr, tableName, op); err != nil {
+				return err
+			}
 		}
-		dbField := field.Tag.Get(b.dbTag)
-		if dbField == "" || dbField == "-" {
+		tag := parseFieldTag(field.Tag.Get(b.dbTag))
+		if tag.ignored() {
 			continue
 		}
+		dbField := tag.name
 		fieldVal := reflectVal.Field(i)
 		if !fieldVal.CanInterface() {
 			continue
 		}
-		if ok := b.shouldSkipField(fieldVal, dbField); ok {
+		if b.isVersionTag(tag) {
+			b.version = &versionLock{column: dbField, current: structFieldValue(fieldVal)}
 			continue
 		}
-		fial := fieldVal.Interface()
-		switch tval := fial.(type) {
-		case string:
-			b.fieldValue = append(b.fieldValue, tval)
-			*fieldArr = append(*fieldArr, fmt.Sprintf("`%s`.`%s` = ?", tableName, dbField))
-		case int, int8, int32, int16, int64, uint, uint8, uint16, uint32, uint64,
-			float32, float64:
-			b.fieldValue = append(b.fieldValue, tval)
-			*fieldArr = append(*fieldArr, fmt.Sprintf("`%s`.`%s` = ?", tableName, dbField))
-		case time.Time:
-			b.fieldValue = append(b.fieldValue, tval)
-			*fieldArr = append(*fieldArr, fmt.Sprintf("`%s`.`%s` = ?", tableName, dbField))
-		case []any:
+		if ok := b.skipStructField(fieldVal, tag, op); ok {
+			continue
+		}
+		fial := structFieldValue(fieldVal)
+		if tval, ok := fial.([]any); ok {
 			if len(tval) < 3 {
 				return fmt.Errorf("字段 %s 的运算表达式格式错误，需要 []any{字段名, 运算符, 值}", dbField)
 			}
 			b.fieldValue = append(b.fieldValue, tval[2])
-			*fieldArr = append(*fieldArr, fmt.Sprintf("`%s`.`%s` = `%s`.`%s`%s?", tableName, dbField, tableName, tval[0], tval[1]))
+			*fieldArr = append(*fieldArr, fmt.Sprintf("%s = %s%s?", b.setCol(tableName, dbField), b.quoteCol(tableName, fmt.Sprint(tval[0])), tval[1]))
+			continue
 		}
+		if _, ok := bindValue(fial); !ok {
+			if b.isStrict() {
+				return fmt.Errorf("严格模式: 字段 %s 的值类型 %T 不受支持", dbField, fial)
+			}
+			continue
+		}
+		b.fieldValue = append(b.fieldValue, fial)
+		*fieldArr = append(*fieldArr, fmt.Sprintf("%s = ?", b.setCol(tableName, dbField)))
 
 	}
 	return nil
 }
 
 // BuildIncrement 使用 map 构建字段累加更新 SQL（SET field = field + ?）
-func (b *sqlBuilder) BuildIncrement(option map[string]any) (string, []any, error) {
+func (b *sqlBuilder) BuildIncrement(option map[string]any) (sqlStr string, args []any, err error) {
+	defer b.observe("BuildIncrement")(&sqlStr, &args, &err)
+	return b.buildStep(option, "+")
+}
+
+// BuildDecrement 使用 map 构建字段累减更新 SQL（SET field = field - ?）
+func (b *sqlBuilder) BuildDecrement(option map[string]any) (sqlStr string, args []any, err error) {
+	defer b.observe("BuildDecrement")(&sqlStr, &args, &err)
+	return b.buildStep(option, "-")
+}
+
+// buildStep 构建字段累加/累减更新 SQL，op 为 "+" 或 "-"
+func (b *sqlBuilder) buildStep(option map[string]any, op string) (string, []any, error) {
+	b = b.prepare()
+	if err := b.checkErr(); err != nil {
+		return "", nil, err
+	}
 	if err := validateMapKeys(option); err != nil {
 		return "", nil, err
 	}
@@ -1433,247 +1970,316 @@ func (b *sqlBuilder) BuildIncrement(option map[string]any) (string, []any, error
 	if b.alias != "" {
 		tableName = b.alias
 	}
-	for k := range option {
+	for _, k := range b.orderedKeys(option) {
 		if valsBuilder.Len() > 0 {
 			valsBuilder.WriteByte(',')
 		}
-		valsBuilder.WriteString(fmt.Sprintf("`%s`.`%s` = `%s`.`%s` + ?", tableName, k, tableName, k))
+		valsBuilder.WriteString(fmt.Sprintf("%s = %s %s ?", b.setCol(tableName, k), b.quoteCol(tableName, k), op))
 		b.fieldValue = append(b.fieldValue, option[k])
 	}
-	b.SqlStr = fmt.Sprintf("update `%s` as `%s` set %s", b.tableName, tableName, valsBuilder.String())
+	b.SqlStr = b.buildUpdateHead(valsBuilder.String())
 
-	whStr, whArgs := b.whr.ParseWhere()
+	whStr, whArgs, err := b.buildWhere()
+	if err != nil {
+		return "", nil, err
+	}
 	if whStr != "" {
-		b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, whStr)
+		b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, appendConds(whStr, b.extraConds()...))
 	} else {
 		return "", nil, errors.New("更新操作必须有一个条件")
 	}
 	if len(whArgs) > 0 {
 		b.fieldValue = append(b.fieldValue, whArgs...)
 	}
+	b.fieldValue = append(b.fieldValue, b.extraArgs()...)
 
 	// ORDER BY and LIMIT for UPDATE
-	if ob := b.buildOrderBy(); ob != "" {
-		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, ob)
+	sqlStr, err := b.appendOrderLimit(b.SqlStr)
+	if err != nil {
+		return "", nil, err
 	}
-	if lm := b.buildLimit(); lm != "" {
-		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, lm)
+	b.SqlStr = sqlStr
+
+	return rebind(b.getDialect(), b.SqlStr), b.fieldValue, nil
+}
+
+// setCol 渲染 UPDATE SET 左侧的列，方言不支持时不带表别名
+func (b *sqlBuilder) setCol(tableName, field string) string {
+	if b.getDialect().Supports(FeatureQualifiedSet) {
+		return b.quoteCol(tableName, field)
 	}
+	return b.quote(field)
+}
 
-	return b.SqlStr, b.fieldValue, nil
+// buildMapSet 构建 map 更新的 SET 部分，参数追加到 b.fieldValue，col 用于引用被更新的列
+// 值为 nil 或 nil 指针时写入 NULL，[]any{字段名, 运算符, 值} 表示字段运算，
+// 其他值需要能直接绑定（见 bindValue），不支持的类型返回错误
+func (b *sqlBuilder) buildMapSet(option map[string]any, tableName string, col func(table, field string) string) (string, error) {
+	sets := make([]string, 0, len(option))
+	for _, k := range b.orderedKeys(option) {
+		v := option[k]
+		if expr, ok := v.([]any); ok {
+			if len(expr) < 3 {
+				return "", fmt.Errorf("字段 %s 的运算表达式格式错误，需要 []any{字段名, 运算符, 值}", k)
+			}
+			b.fieldValue = append(b.fieldValue, expr[2])
+			sets = append(sets, fmt.Sprintf("%s = %s%s?", col(tableName, k), b.quoteCol(tableName, fmt.Sprint(expr[0])), expr[1]))
+			continue
+		}
+		val, ok := bindValue(v)
+		if !ok {
+			return "", fmt.Errorf("字段 %s 的值类型 %T 不受支持", k, v)
+		}
+		b.fieldValue = append(b.fieldValue, val)
+		sets = append(sets, fmt.Sprintf("%s = ?", col(tableName, k)))
+	}
+	if len(sets) == 0 {
+		return "", errors.New("没有可更新的字段")
+	}
+	return strings.Join(sets, ","), nil
 }
 
-// BuildDecrement 使用 map 构建字段累减更新 SQL（SET field = field - ?）
-func (b *sqlBuilder) BuildDecrement(option map[string]any) (string, []any, error) {
-	if err := validateMapKeys(option); err != nil {
-		return "", nil, err
+// buildUpdateHead 按方言构建 UPDATE ... SET 部分
+func (b *sqlBuilder) buildUpdateHead(set string) string {
+	tableName := b.tableName
+	if b.alias != "" {
+		tableName = b.alias
 	}
-	var valsBuilder strings.Builder
+	if b.getDialect().Supports(FeatureUpdateFrom) {
+		return fmt.Sprintf("update %s set %s from %s as %s", b.quote(tableName), set, b.quote(b.tableName), b.quote(tableName))
+	}
+	return fmt.Sprintf("update %s as %s set %s", b.quote(b.tableName), b.quote(tableName), set)
+}
+
+// buildDeleteHead 按方言构建 DELETE ... FROM 部分
+// MariaDB 的 RETURNING 只能用于单表 DELETE，设置了 returning 时使用 delete from 形式，别名与表名相同时不加 as
+func (b *sqlBuilder) buildDeleteHead() string {
 	tableName := b.tableName
 	if b.alias != "" {
 		tableName = b.alias
 	}
-	for k := range option {
-		if valsBuilder.Len() > 0 {
-			valsBuilder.WriteByte(',')
+	if b.getDialect().Supports(FeatureDeleteJoin) {
+		if len(b.returning) == 0 {
+			return fmt.Sprintf("delete %s from %s as %s", b.quote(tableName), b.quote(b.tableName), b.quote(tableName))
+		}
+		if tableName == b.tableName {
+			return fmt.Sprintf("delete from %s", b.quote(b.tableName))
 		}
-		valsBuilder.WriteString(fmt.Sprintf("`%s`.`%s` = `%s`.`%s` - ?", tableName, k, tableName, k))
-		b.fieldValue = append(b.fieldValue, option[k])
 	}
-	b.SqlStr = fmt.Sprintf("update `%s` as `%s` set %s", b.tableName, tableName, valsBuilder.String())
+	return fmt.Sprintf("delete from %s as %s", b.quote(b.tableName), b.quote(tableName))
+}
 
-	whStr, whArgs := b.whr.ParseWhere()
-	if whStr != "" {
-		b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, whStr)
-	} else {
-		return "", nil, errors.New("更新操作必须有一个条件")
+// appendOrderLimit 为 UPDATE/DELETE 追加 ORDER BY 和 LIMIT
+func (b *sqlBuilder) appendOrderLimit(sqlStr string) (string, error) {
+	ob := b.buildOrderBy()
+	lm := b.buildLimit()
+	if ob == "" && lm == "" {
+		return sqlStr, nil
 	}
-	if len(whArgs) > 0 {
-		b.fieldValue = append(b.fieldValue, whArgs...)
+	if err := b.require(FeatureUpdateOrderLimit, "UPDATE/DELETE 的 ORDER BY/LIMIT"); err != nil {
+		return "", err
 	}
-
-	// ORDER BY and LIMIT for UPDATE
-	if ob := b.buildOrderBy(); ob != "" {
-		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, ob)
+	if ob != "" {
+		sqlStr = fmt.Sprintf("%s %s", sqlStr, ob)
 	}
-	if lm := b.buildLimit(); lm != "" {
-		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, lm)
+	if lm != "" {
+		sqlStr = fmt.Sprintf("%s %s", sqlStr, lm)
 	}
-
-	return b.SqlStr, b.fieldValue, nil
+	return sqlStr, nil
 }
 
 // BuildDelete 构建删除 SQL，必须设置 WHERE 条件
-func (b *sqlBuilder) BuildDelete() (string, []any, error) {
-	tableName := b.tableName
-	if b.alias != "" {
-		tableName = b.alias
+func (b *sqlBuilder) BuildDelete() (sqlStr string, args []any, err error) {
+	defer b.observe("BuildDelete")(&sqlStr, &args, &err)
+	b = b.prepare()
+	if err := b.checkErr(); err != nil {
+		return "", nil, err
 	}
-	b.SqlStr = fmt.Sprintf("delete `%s` from `%s` as `%s`", tableName, b.tableName, tableName)
+	b.SqlStr = b.buildDeleteHead()
 
-	whStr, whArgs := b.whr.ParseWhere()
+	whStr, whArgs, err := b.buildWhere()
+	if err != nil {
+		return "", nil, err
+	}
 	if whStr == "" || len(whArgs) == 0 {
 		return "", nil, errors.New("删除操作必须有一个条件")
 	}
-	b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, whStr)
+	b.SqlStr = fmt.Sprintf("%s where %s", b.SqlStr, appendConds(whStr, b.extraConds()...))
 
 	b.fieldValue = append(b.fieldValue, whArgs...)
+	b.fieldValue = append(b.fieldValue, b.extraArgs()...)
 
 	// ORDER BY and LIMIT for DELETE
-	if ob := b.buildOrderBy(); ob != "" {
-		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, ob)
+	sqlStr, err = b.appendOrderLimit(b.SqlStr)
+	if err != nil {
+		return "", nil, err
 	}
-	if lm := b.buildLimit(); lm != "" {
-		b.SqlStr = fmt.Sprintf("%s %s", b.SqlStr, lm)
+	b.SqlStr = sqlStr
+
+	// RETURNING
+	if b.SqlStr, err = b.appendReturning(b.SqlStr, FeatureReturning); err != nil {
+		return "", nil, err
 	}
 
-	return b.SqlStr, b.fieldValue, nil
+	return rebind(b.getDialect(), b.SqlStr), b.fieldValue, nil
 }
 
-// BuildTruncate 构建 TRUNCATE TABLE SQL
-func (b *sqlBuilder) BuildTruncate() (string, error) {
-	return fmt.Sprintf("truncate table `%s`", b.tableName), nil
+// BuildTruncate 构建 TRUNCATE TABLE SQL，方言不支持时退化为 DELETE FROM
+func (b *sqlBuilder) BuildTruncate() (sqlStr string, err error) {
+	defer b.observe("BuildTruncate")(&sqlStr, nil, &err)
+	if err := b.checkErr(); err != nil {
+		return "", err
+	}
+	if !b.getDialect().Supports(FeatureTruncate) {
+		return fmt.Sprintf("delete from %s", b.quote(b.tableName)), nil
+	}
+	return fmt.Sprintf("truncate table %s", b.quote(b.tableName)), nil
 }
 
-// BuildSoftDelete 构建软删除 SQL（UPDATE deleted_at = NOW()）
-func (b *sqlBuilder) BuildSoftDelete() (string, []any, error) {
-	return b.BuildMapUpdate(map[string]any{
+// BuildSoftDelete 构建软删除 SQL（UPDATE deleted_at = NOW()），需先通过 SoftDelete 设置字段
+// 已软删除的行不会被重复标记；开启乐观锁时不校验版本号，只将版本号加 1
+func (b *sqlBuilder) BuildSoftDelete() (sqlStr string, args []any, err error) {
+	defer b.observe("BuildSoftDelete")(&sqlStr, &args, &err)
+	if b.softDeleteField == "" {
+		return "", nil, errors.New("未设置软删除字段，请先调用 SoftDelete")
+	}
+	c := b.silent()
+	return c.BuildMapUpdate(c.bumpVersion(map[string]any{
 		b.softDeleteField: time.Now(),
-	})
+	}))
 }
 
 // BuildSelectCount 构建 SELECT COUNT 查询（包装原查询为子查询）
-func (b *sqlBuilder) BuildSelectCount() (string, []any, error) {
-	innerSql, innerArgs, err := b.BuildSelect()
+func (b *sqlBuilder) BuildSelectCount() (sqlStr string, args []any, err error) {
+	defer b.observe("BuildSelectCount")(&sqlStr, &args, &err)
+	innerSql, innerArgs, err := b.silent().BuildSelect()
 	if err != nil {
 		return "", nil, err
 	}
-	return fmt.Sprintf("select count(*) as `_count` from (%s) as `_count`", innerSql), innerArgs, nil
+	return fmt.Sprintf("select count(*) as %s from (%s) as %s", b.quote("_count"), innerSql, b.quote("_count")), innerArgs, nil
 }
 
 // BuildExists 构建 SELECT EXISTS 查询
-func (b *sqlBuilder) BuildExists() (string, []any, error) {
-	innerSql, innerArgs, err := b.BuildSelect()
+func (b *sqlBuilder) BuildExists() (sqlStr string, args []any, err error) {
+	defer b.observe("BuildExists")(&sqlStr, &args, &err)
+	innerSql, innerArgs, err := b.silent().BuildSelect()
 	if err != nil {
 		return "", nil, err
 	}
-	return fmt.Sprintf("select exists(%s) as `_exists`", innerSql), innerArgs, nil
+	return fmt.Sprintf("select exists(%s) as %s", innerSql, b.quote("_exists")), innerArgs, nil
 }
 
 // BuildUpdateWithJoin 构建带 JOIN 的 UPDATE SQL
-func (b *sqlBuilder) BuildUpdateWithJoin(option map[string]any) (string, []any, error) {
+func (b *sqlBuilder) BuildUpdateWithJoin(option map[string]any) (sqlStr string, args []any, err error) {
+	defer b.observe("BuildUpdateWithJoin")(&sqlStr, &args, &err)
+	b = b.prepare()
+	if err := b.checkErr(); err != nil {
+		return "", nil, err
+	}
 	if err := validateMapKeys(option); err != nil {
 		return "", nil, err
 	}
+	if err := b.require(FeatureUpdateJoin, "UPDATE ... JOIN"); err != nil {
+		return "", nil, err
+	}
 	tableName := b.tableName
 	if b.alias != "" {
 		tableName = b.alias
 	}
-	var valsBuilder strings.Builder
-	for k, v := range option {
-		if valsBuilder.Len() > 0 {
-			valsBuilder.WriteByte(',')
-		}
-		switch val := v.(type) {
-		case string:
-			b.fieldValue = append(b.fieldValue, val)
-			valsBuilder.WriteString(fmt.Sprintf("`%s`.`%s` = ?", tableName, k))
-		case int, int8, int32, int16, int64, uint, uint8, uint16, uint32, uint64,
-			float32, float64:
-			b.fieldValue = append(b.fieldValue, val)
-			valsBuilder.WriteString(fmt.Sprintf("`%s`.`%s` = ?", tableName, k))
-		case time.Time:
-			b.fieldValue = append(b.fieldValue, val)
-			valsBuilder.WriteString(fmt.Sprintf("`%s`.`%s` = ?", tableName, k))
-		case []any:
-			if len(val) < 3 {
-				return "", nil, fmt.Errorf("字段 %s 的运算表达式格式错误，需要 []any{字段名, 运算符, 值}", k)
-			}
-			b.fieldValue = append(b.fieldValue, val[2])
-			valsBuilder.WriteString(fmt.Sprintf("`%s`.`%s` = `%s`.`%s`%s?", tableName, k, tableName, val[0], val[1]))
-		}
+	setStr, err := b.buildMapSet(option, tableName, b.quoteCol)
+	if err != nil {
+		return "", nil, err
 	}
 
-	updateSql := fmt.Sprintf("update `%s`", b.tableName)
+	updateSql := fmt.Sprintf("update %s", b.quote(b.tableName))
 	if b.alias != "" {
-		updateSql += fmt.Sprintf(" as `%s`", b.alias)
+		updateSql += fmt.Sprintf(" as %s", b.quote(b.alias))
 	}
 
 	if joinStr := b.buildJoinClause(); joinStr != "" {
 		updateSql += " " + joinStr
 	}
 
-	updateSql += fmt.Sprintf(" set %s", valsBuilder.String())
+	updateSql += fmt.Sprintf(" set %s", setStr)
 
-	whStr, whArgs := b.whr.ParseWhere()
+	whStr, whArgs, err := b.buildWhere()
+	if err != nil {
+		return "", nil, err
+	}
 	if whStr != "" {
-		updateSql = fmt.Sprintf("%s where %s", updateSql, whStr)
+		updateSql = fmt.Sprintf("%s where %s", updateSql, appendConds(whStr, b.extraConds()...))
 	} else {
 		return "", nil, errors.New("更新操作必须有一个条件")
 	}
 	if len(whArgs) > 0 {
 		b.fieldValue = append(b.fieldValue, whArgs...)
 	}
+	b.fieldValue = append(b.fieldValue, b.extraArgs()...)
 
 	// ORDER BY and LIMIT support for UPDATE
-	if ob := b.buildOrderBy(); ob != "" {
-		updateSql = fmt.Sprintf("%s %s", updateSql, ob)
-	}
-	if lm := b.buildLimit(); lm != "" {
-		updateSql = fmt.Sprintf("%s %s", updateSql, lm)
+	updateSql, err = b.appendOrderLimit(updateSql)
+	if err != nil {
+		return "", nil, err
 	}
 
 	b.SqlStr = updateSql
 	if b.err != nil {
 		return "", nil, b.err
 	}
-	return b.SqlStr, b.fieldValue, nil
+	return rebind(b.getDialect(), b.SqlStr), b.fieldValue, nil
 }
 
 // BuildDeleteWithJoin 构建带 JOIN 的 DELETE SQL
-func (b *sqlBuilder) BuildDeleteWithJoin() (string, []any, error) {
+func (b *sqlBuilder) BuildDeleteWithJoin() (sqlStr string, args []any, err error) {
+	defer b.observe("BuildDeleteWithJoin")(&sqlStr, &args, &err)
+	b = b.prepare()
+	if err := b.checkErr(); err != nil {
+		return "", nil, err
+	}
+	if err := b.require(FeatureDeleteJoin, "DELETE ... JOIN"); err != nil {
+		return "", nil, err
+	}
 	tableName := b.tableName
 	if b.alias != "" {
 		tableName = b.alias
 	}
 
-	deleteSql := fmt.Sprintf("delete `%s` from `%s`", tableName, b.tableName)
+	deleteSql := fmt.Sprintf("delete %s from %s", b.quote(tableName), b.quote(b.tableName))
 	if b.alias != "" {
-		deleteSql += fmt.Sprintf(" as `%s`", b.alias)
+		deleteSql += fmt.Sprintf(" as %s", b.quote(b.alias))
 	}
 
 	if joinStr := b.buildJoinClause(); joinStr != "" {
 		deleteSql += " " + joinStr
 	}
 
-	whStr, whArgs := b.whr.ParseWhere()
+	whStr, whArgs, err := b.buildWhere()
+	if err != nil {
+		return "", nil, err
+	}
 	if whStr == "" || len(whArgs) == 0 {
 		return "", nil, errors.New("删除操作必须有一个条件")
 	}
-	deleteSql = fmt.Sprintf("%s where %s", deleteSql, whStr)
+	deleteSql = fmt.Sprintf("%s where %s", deleteSql, appendConds(whStr, b.extraConds()...))
 	b.fieldValue = append(b.fieldValue, whArgs...)
+	b.fieldValue = append(b.fieldValue, b.extraArgs()...)
 
 	// ORDER BY and LIMIT for DELETE
-	if ob := b.buildOrderBy(); ob != "" {
-		deleteSql = fmt.Sprintf("%s %s", deleteSql, ob)
-	}
-	if lm := b.buildLimit(); lm != "" {
-		deleteSql = fmt.Sprintf("%s %s", deleteSql, lm)
+	deleteSql, err = b.appendOrderLimit(deleteSql)
+	if err != nil {
+		return "", nil, err
 	}
 
 	b.SqlStr = deleteSql
 	if b.err != nil {
 		return "", nil, b.err
 	}
-	return b.SqlStr, b.fieldValue, nil
+	return rebind(b.getDialect(), b.SqlStr), b.fieldValue, nil
 }
 
-// shouldSkipField 判断字段是否应该跳过（值为零值且未在 zeroFieldMap/emptyFieldMap 中声明需要更新时跳过）
+// shouldSkipField 判断字段是否应该跳过（值为零值或 NULL 且未在 zeroFieldMap/emptyFieldMap 中声明需要更新时跳过）
+// 声明过的字段为 nil 指针或无效的 sql.Null* 时写入 NULL
 func (b *sqlBuilder) shouldSkipField(val reflect.Value, dbField string) bool {
-	// 1. 指针类型且为 nil -> 跳过
-	if val.Kind() == reflect.Ptr && val.IsNil() {
-		return true
-	}
 	jump := false
 	switch val.Kind() {
 	case reflect.String:
@@ -1684,9 +2290,12 @@ func (b *sqlBuilder) shouldSkipField(val reflect.Value, dbField string) bool {
 		jump = val.Uint() == 0
 	case reflect.Float32, reflect.Float64:
 		jump = val.Float() == 0
+	case reflect.Bool:
+		// false 与其他零值一致，部分字段更新时不覆盖；需要写入 false 时使用 UpdateZeroField、*bool 或 sql.NullBool
+		jump = !val.Bool()
 	default:
-		// 其他类型默认不跳
-		jump = false
+		// nil 指针、nil []byte、无效的 sql.Null* 等 NULL 值跳过，其他类型默认不跳
+		jump = isNullValue(val)
 	}
 	// 等于0仍要更新
 	if _, ok := b.zeroFieldMap[dbField]; ok {
@@ -1698,3 +2307,15 @@ func (b *sqlBuilder) shouldSkipField(val reflect.Value, dbField string) bool {
 	}
 	return jump
 }
+
+// structFieldValue 返回结构体字段用于绑定的值，指针解引用，NULL 值为 nil，不支持的类型原样返回
+func structFieldValue(val reflect.Value) any {
+	if isNullValue(val) {
+		return nil
+	}
+	raw := fieldInterface(val)
+	if v, ok := bindValue(raw); ok {
+		return v
+	}
+	return raw
+}
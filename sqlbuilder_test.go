@@ -1,6 +1,11 @@
 package sqlbuilder
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -318,12 +323,48 @@ func TestInsert_SliceStructInsert(t *testing.T) {
 	if !strings.Contains(sql, "insert into") {
 		t.Errorf("expected INSERT, got: %s", sql)
 	}
-	if len(args) != 11 {
-		t.Errorf("expected 11 args, got %d", len(args))
+	// 原先按行各自跳过零值，5 列却只有 11 个参数（前两行各 3 个），语句无法执行；
+	// 现在列为各行的并集，前两行的 act、step 绑定字段的实际值 ""，共 3 行 x 5 列
+	if len(args) != 15 {
+		t.Errorf("expected 15 args, got %d", len(args))
 	}
 	t.Logf("INSERT slice struct: %s | args: %v", sql, args)
 }
 
+func TestInsert_SliceStructMixedRows(t *testing.T) {
+	rows := []Person{
+		{Id: 1, Name: "孙悟空", Speak: Speak{Act: "attack"}},
+		{Id: 2, Name: "唐僧", Age: 25},
+	}
+	sql, args, err := From("person").BuildSliceStructInsert(&rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "insert into `person` (`id`,`name`,`age`,`act`) values (?,?,?,?),(?,?,?,?)"
+	if sql != expected {
+		t.Errorf("expected %s, got %s", expected, sql)
+	}
+	// 某行跳过的零值绑定字段的实际值，而不是 NULL
+	want := []any{1, "孙悟空", 0, "attack", 2, "唐僧", 25, ""}
+	if fmt.Sprint(args) != fmt.Sprint(want) || args[2] != 0 || args[7] != "" {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+
+	// 拆分后的每条语句使用相同的列
+	stmts, err := From("person").BuildSliceStructInsertBatch(&rows, BatchLimit{MaxRows: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stmts) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(stmts))
+	}
+	for _, st := range stmts {
+		if st.SQL != "insert into `person` (`id`,`name`,`age`,`act`) values (?,?,?,?)" || len(st.Args) != 4 {
+			t.Errorf("unexpected statement: %s %v", st.SQL, st.Args)
+		}
+	}
+}
+
 func TestInsert_SliceStructNamedInsert(t *testing.T) {
 	sql, err := From("person").As("a").BuildSliceStructNamedInsert(&[]Person{
 		{Id: 1, Name: "孙悟空", Age: 23},
@@ -1245,6 +1286,20 @@ func TestWhere_JsonExtract(t *testing.T) {
 	t.Logf("JSON_EXTRACT: %s | args: %v", sql, args)
 }
 
+func TestWhere_JsonExtractPathIsBound(t *testing.T) {
+	path := "$.a') or 1=1 -- "
+	sql, args, err := From("t").WhereAnd("doc", "json_extract", path).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql != "select `t`.* from `t` as `t` where json_extract (`t`.`doc`, ?)" {
+		t.Errorf("unexpected sql: %s", sql)
+	}
+	if len(args) != 1 || args[0] != path {
+		t.Errorf("expected path as bound arg, got %v", args)
+	}
+}
+
 func TestWhere_JsonField(t *testing.T) {
 	sql, args, err := From("user").As("u").
 		WhereAnd(JsonField("u", "data", "->>", "$.name"), "=", "Tom").
@@ -2182,3 +2237,1955 @@ func TestParamOrder_SelectSubquery_Where_Correct(t *testing.T) {
 	}
 	t.Logf("Param order OK: %v", args)
 }
+
+// ========== Dialect Tests ==========
+
+func TestDialect_PostgresSelect(t *testing.T) {
+	sql, args, err := From("user").Dialect(Postgres).As("u").
+		Select("id", "name").
+		WhereAnd("id", "in", From("order").Select("user_id").WhereAnd("amount", ">", 10)).
+		WhereAnd("name", "like", "x").
+		Page(2, 10).
+		BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `select "u"."id","u"."name" from "user" as "u" where "u"."id" in (select "order"."user_id" from "order" as "order" where "order"."amount" > $1) and "u"."name" like $2 limit 10 offset 10`
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	if len(args) != 2 || args[0] != 10 || args[1] != "%x%" {
+		t.Errorf("unexpected args: %v", args)
+	}
+	t.Logf("Postgres SELECT: %s | args: %v", sql, args)
+}
+
+func TestDialect_SQLServerLimit(t *testing.T) {
+	sql, args, err := From("user").Dialect(SQLServer).Select("id").WhereAnd("a", 1).Limit(5).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "select [user].[id] from [user] as [user] where [user].[a] = @p1 order by (select null) offset 0 rows fetch next 5 rows only"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	t.Logf("SQL Server SELECT: %s | args: %v", sql, args)
+}
+
+func TestDialect_SQLiteUnion(t *testing.T) {
+	sql, args, err := From("a").Dialect(SQLite).Select("id").
+		Union(From("b").Select("id").WhereAnd("x", 1)).
+		BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `select "a"."id" from "a" as "a" union select "b"."id" from "b" as "b" where "b"."x" = ?`
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	t.Logf("SQLite UNION: %s | args: %v", sql, args)
+}
+
+func TestDialect_PostgresUpdateDelete(t *testing.T) {
+	sql, args, err := From("user").Dialect(Postgres).WhereAnd("id", 1).
+		BuildMapUpdate(map[string]any{"n": []any{"n", "+", 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `update "user" as "user" set "n" = "user"."n"+$1 where "user"."id" = $2`
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	t.Logf("Postgres UPDATE: %s | args: %v", sql, args)
+
+	sql, _, err = From("user").Dialect(Postgres).WhereAnd("id", 1).BuildDelete()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql != `delete from "user" as "user" where "user"."id" = $1` {
+		t.Errorf("unexpected DELETE: %s", sql)
+	}
+
+	_, _, err = From("user").Dialect(Postgres).WhereAnd("id", 1).Limit(1).BuildDelete()
+	if err == nil {
+		t.Error("expected error for DELETE ... LIMIT on postgres")
+	}
+}
+
+func TestDialect_SQLServerUpdate(t *testing.T) {
+	sql, _, err := From("user").Dialect(SQLServer).As("u").WhereAnd("id", 1).
+		BuildMapUpdate(map[string]any{"name": "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "update [u] set [name] = @p1 from [user] as [u] where [u].[id] = @p2"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	t.Logf("SQL Server UPDATE: %s", sql)
+}
+
+func TestDialect_UnsupportedFeature(t *testing.T) {
+	b := From("user").Dialect(Postgres)
+	sql, _ := b.BuildMapInsertIgnore(map[string]any{"a": 1})
+	if sql != "" || b.err == nil {
+		t.Errorf("expected INSERT IGNORE to be rejected on postgres, got: %s", sql)
+	}
+	_, _, err := From("user").Dialect(Postgres).Select("id").SqlNoCache().BuildSelect()
+	if err == nil {
+		t.Error("expected SQL_NO_CACHE to be rejected on postgres")
+	}
+	_, _, err = From("user").Dialect(SQLite).StraightJoin("b", "b").BuildSelect()
+	if err == nil {
+		t.Error("expected STRAIGHT_JOIN to be rejected on sqlite")
+	}
+	t.Logf("Unsupported features rejected: %v", err)
+}
+
+func TestDialect_FullJoinNative(t *testing.T) {
+	sql, _, err := From("a").Dialect(Postgres).FullJoin("b", "b", "id", "aid").BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(sql, "full outer join") {
+		t.Errorf("expected native FULL OUTER JOIN, got: %s", sql)
+	}
+	t.Logf("Postgres FULL JOIN: %s", sql)
+}
+
+func TestDialect_InsertPlaceholders(t *testing.T) {
+	sql, args := From("user").Dialect(Postgres).BuildSliceMapInsert([]map[string]any{
+		{"a": 1}, {"a": 2},
+	})
+	if sql != `insert into "user" ("a") values ($1),($2)` {
+		t.Errorf("unexpected INSERT: %s", sql)
+	}
+	t.Logf("Postgres INSERT: %s | args: %v", sql, args)
+}
+
+func TestDialect_RebindSkipsQuotes(t *testing.T) {
+	got := rebind(Postgres, "select '?' , \"a?\" from t where x = ? and y = ?")
+	expected := "select '?' , \"a?\" from t where x = $1 and y = $2"
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestDialect_SubqueryInheritsDialect(t *testing.T) {
+	sub := From("t").Dialect(MySQL).Select("id")
+	sql, _, err := From("a").Dialect(Postgres).WhereAnd("id", "in", sub).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 显式指定方言的子查询保持自己的方言
+	if !strings.Contains(sql, "`t`") {
+		t.Errorf("expected explicit sub dialect to be kept, got: %s", sql)
+	}
+	sql, _, err = From("a").Dialect(Postgres).WhereAnd("id", "in", From("t").Select("id")).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(sql, `"t"."id"`) {
+		t.Errorf("expected sub query to inherit postgres dialect, got: %s", sql)
+	}
+}
+
+// ========== Condition Validation Tests ==========
+
+func TestWhere_ValueWithSpecialChars(t *testing.T) {
+	sql, args, err := From("user").
+		WhereAnd("email", "a@b.com").
+		WhereAnd("title", "like", "C#").
+		WhereAnd("note", "=", "a; b -- c").
+		BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "select `user`.* from `user` as `user` where `user`.`email` = ? and `user`.`title` like ? and `user`.`note` = ?"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	if len(args) != 3 || args[0] != "a@b.com" || args[1] != "%C#%" {
+		t.Errorf("unexpected args: %v", args)
+	}
+	t.Logf("Values with special chars kept: %s | args: %v", sql, args)
+}
+
+func TestWhere_RejectedConditionReportsError(t *testing.T) {
+	cases := map[string]*sqlBuilder{
+		"illegal operator":     From("user").WhereAnd("id", "= 1 or 1=1 --", 1),
+		"non-string operator":  From("user").WhereAnd("id", 1, 2),
+		"illegal table alias":  From("user").WhereAnd("id", "=", 1, "u;"),
+		"illegal relation":     From("user").WhereAnd("id", "=", 1, "u", "or 1=1"),
+		"illegal nested field": From("user").WhereAnd([][]any{{"id", 1}, {"name;", "x"}}),
+		"bad nested arity":     From("user").WhereAnd([][]any{{"id"}}),
+		"unsupported type":     From("user").WhereAnd("status"),
+		"illegal having field": From("user").Group("id").HavingWhereAnd("cnt#", ">", 1),
+	}
+	for name, b := range cases {
+		if _, _, err := b.BuildSelect(); err == nil {
+			t.Errorf("%s: expected error", name)
+		} else {
+			t.Logf("%s: %v", name, err)
+		}
+	}
+}
+
+// ========== Strict Mode Tests ==========
+
+func TestStrict_SkippedFragmentsBecomeErrors(t *testing.T) {
+	cases := map[string]func() *sqlBuilder{
+		"bad Fn":         func() *sqlBuilder { return From("user").Select(Fn("count;", "total", "*")) },
+		"bad SField":     func() *sqlBuilder { return From("user").Select(SField("u", "id#", "")) },
+		"bad WinFn":      func() *sqlBuilder { return From("user").Select(WinFn("rank", "r").Partition("dept;")) },
+		"bad order row":  func() *sqlBuilder { return From("user").Order([][]any{{"id"}}) },
+		"bad index name": func() *sqlBuilder { return From("user").UseIndex("idx;") },
+	}
+	for name, mk := range cases {
+		if _, _, err := mk().BuildSelect(); err != nil {
+			t.Errorf("%s: expected lenient mode to keep building, got: %v", name, err)
+		}
+		if _, _, err := mk().Strict().BuildSelect(); err == nil {
+			t.Errorf("%s: expected strict mode error", name)
+		} else {
+			t.Logf("%s: %v", name, err)
+		}
+	}
+}
+
+// 无法渲染的条件会扩大结果集，非严格模式下同样返回错误
+func TestWhere_DroppedConditionAlwaysErrors(t *testing.T) {
+	cases := map[string]*sqlBuilder{
+		"bad JsonField":       From("user").WhereAnd(JsonField("u", "data", "->>", "$.a;b"), "=", 1),
+		"unknown operator":    From("user").WhereAnd("id", "lik", "x"),
+		"between arity":       From("user").WhereAnd("age", "between", []any{1, 2, 3}),
+		"between one element": From("user").WhereAnd("age", "between", []any{1}),
+		"unsupported value":   From("user").WhereAnd("tags", "=", map[string]int{"a": 1}),
+		"empty group field":   From("user").WhereAnd([][]any{{"", "=", 1}}),
+		"unknown having op":   From("user").Group("id").HavingWhereAnd("cnt", "lik", 1),
+	}
+	for name, b := range cases {
+		if sql, _, err := b.BuildSelect(); err == nil {
+			t.Errorf("%s: expected error, got %s", name, sql)
+		} else {
+			t.Logf("%s: %v", name, err)
+		}
+	}
+	if _, _, err := From("user").WhereAnd("id", "lik", "x").BuildDelete(); err == nil {
+		t.Error("expected error for DELETE with a dropped condition")
+	}
+}
+
+func TestStrict_MapUpdateUnsupportedValue(t *testing.T) {
+	_, _, err := From("user").Strict().WhereAnd("id", 1).
+		BuildMapUpdate(map[string]any{"tags": map[string]int{"a": 1}})
+	if err == nil {
+		t.Fatal("expected strict mode error for unsupported update value")
+	}
+	t.Logf("Strict update: %v", err)
+}
+
+func TestStrict_DefaultStrict(t *testing.T) {
+	SetDefaultStrict(true)
+	defer SetDefaultStrict(false)
+	_, _, err := From("user").WhereAnd("id", "almost", 1).BuildSelect()
+	if err == nil {
+		t.Fatal("expected package-level strict mode to apply")
+	}
+	t.Logf("Default strict: %v", err)
+}
+
+// ========== Idempotent Build Tests ==========
+
+func TestIdempotent_BuildSelectTwice(t *testing.T) {
+	b := From("user").As("u").
+		With("vip", From("vip").Select("user_id").WhereAnd("level", ">", 2)).
+		Select("id", Fn("count", "total", "*")).
+		Join("dept", "d", "dept_id", "id").
+		WhereAnd("status", 1).
+		WhereRaw("`u`.`age` > ?", 18).
+		Group("id").
+		HavingWhereAnd("total", ">", 3).
+		Union(From("user_archive").Select("id").WhereAnd("status", 2))
+	sql1, args1, err := b.BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql2, args2, err := b.BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql1 != sql2 {
+		t.Errorf("SQL differs between builds:\n%s\n%s", sql1, sql2)
+	}
+	if len(args1) != 5 || len(args2) != 5 {
+		t.Errorf("expected 5 args each time, got %v and %v", args1, args2)
+	}
+	t.Logf("Idempotent SELECT: %s | args: %v", sql2, args2)
+}
+
+func TestIdempotent_CountThenSelect(t *testing.T) {
+	b := From("user").WhereAnd("status", 1).Page(2, 10)
+	countSql, countArgs, err := b.BuildSelectCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, args, err := b.BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	existsSql, existsArgs, err := b.BuildExists()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range []string{countSql, sql, existsSql} {
+		if !strings.Contains(s, "where `user`.`status` = ?") {
+			t.Errorf("expected WHERE to survive repeated builds, got: %s", s)
+		}
+	}
+	if len(countArgs) != 1 || len(args) != 1 || len(existsArgs) != 1 {
+		t.Errorf("expected 1 arg per build, got %v %v %v", countArgs, args, existsArgs)
+	}
+}
+
+func TestIdempotent_UpdateDeleteTwice(t *testing.T) {
+	b := From("user").WhereAnd("id", 1)
+	for i := 0; i < 2; i++ {
+		sql, args, err := b.BuildMapUpdate(map[string]any{"name": "x"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(args) != 2 {
+			t.Errorf("build %d: expected 2 args, got %v (%s)", i, args, sql)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		_, args, err := b.BuildDelete()
+		if err != nil {
+			t.Fatalf("build %d: unexpected error: %v", i, err)
+		}
+		if len(args) != 1 {
+			t.Errorf("build %d: expected 1 arg, got %v", i, args)
+		}
+	}
+}
+
+func TestIdempotent_RawArgsOrder(t *testing.T) {
+	sql, args, err := From("user").
+		WhereAnd("a", 1).
+		WhereRaw("`user`.`b` = ?", 2).
+		BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 参数顺序必须与占位符顺序一致: [where_arg, raw_arg]
+	if len(args) != 2 || args[0] != 1 || args[1] != 2 {
+		t.Errorf("参数顺序错误: 期望 [1 2], 实际 %v\nSQL: %s", args, sql)
+	}
+}
+
+func TestIdempotent_WhereRawInUpdate(t *testing.T) {
+	sql, args, err := From("user").
+		WhereAnd("id", 1).
+		WhereRaw("`user`.`version` = ?", 3).
+		BuildMapUpdate(map[string]any{"name": "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "update `user` as `user` set `user`.`name` = ? where `user`.`id` = ? and (`user`.`version` = ?)"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	if len(args) != 3 || args[2] != 3 {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+// ========== Clone Tests ==========
+
+func TestClone_BranchesAreIndependent(t *testing.T) {
+	base := From("user").As("u").
+		Select("id", "name").
+		Join("dept", "d", "dept_id", "id").
+		WhereAnd("tenant_id", 7).
+		WhereAnd("status", "in", []any{1, 2})
+
+	list := base.Clone().WhereAnd("name", "like", "%a%").Order([][]any{{"id", "desc"}}).Page(1, 20)
+	count := base.Clone().LeftJoin("role", "r", "role_id", "id")
+
+	baseSql, baseArgs, err := base.BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	listSql, listArgs, err := list.BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	countSql, _, err := count.BuildSelectCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(baseSql, "like") || strings.Contains(baseSql, "order by") || strings.Contains(baseSql, "role") {
+		t.Errorf("branch modifications leaked into base: %s", baseSql)
+	}
+	if len(baseArgs) != 3 || len(listArgs) != 4 {
+		t.Errorf("unexpected args: base=%v list=%v", baseArgs, listArgs)
+	}
+	if !strings.Contains(listSql, "like") || strings.Contains(listSql, "role") {
+		t.Errorf("unexpected list SQL: %s", listSql)
+	}
+	if !strings.Contains(countSql, "left join `role`") || strings.Contains(countSql, "like") {
+		t.Errorf("unexpected count SQL: %s", countSql)
+	}
+}
+
+func TestClone_DeepCopiesValuesAndSubqueries(t *testing.T) {
+	ids := []any{1, 2}
+	sub := From("vip").Select("user_id")
+	base := From("user").
+		WhereAnd("id", "in", ids).
+		WhereAnd("id", "in", sub).
+		With("active", From("user").WhereAnd("status", 1)).
+		Union(From("user_archive").Select("id"))
+	clone := base.Clone()
+
+	// 修改克隆体内部的子查询、CTE、UNION，不应影响原 builder
+	clone.whr.assembleWhere[0][0].Condition[0].Value.([]any)[0] = 99
+	clone.whr.assembleWhere[1][0].Condition[0].Value.(*sqlBuilder).WhereAnd("level", 3)
+	clone.ctes[0].definition.WhereAnd("deleted", 0)
+	clone.unions[0].builder.WhereAnd("archived", 1)
+
+	sql, args, err := base.BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args[1] != 1 {
+		t.Errorf("IN 列表被克隆体修改: %v", args)
+	}
+	for _, s := range []string{"level", "deleted", "archived"} {
+		if strings.Contains(sql, s) {
+			t.Errorf("clone modification %q leaked into base: %s", s, sql)
+		}
+	}
+}
+
+// ========== Column Order Tests ==========
+
+func TestColumnOrder_SortedByDefault(t *testing.T) {
+	row := map[string]any{"name": "a", "age": 1, "email": "x", "city": "bj"}
+	for i := 0; i < 20; i++ {
+		sql, args := From("user").OnDuplicateKey(map[string]any{"name": "b", "age": 2}).BuildMapInsert(row)
+		expected := "insert into `user` (`age`,`city`,`email`,`name`) values (?,?,?,?) on duplicate key update `age` = ?, `name` = ?"
+		if sql != expected {
+			t.Fatalf("expected:\n%s\ngot:\n%s", expected, sql)
+		}
+		if args[0] != 1 || args[3] != "a" || args[4] != 2 || args[5] != "b" {
+			t.Fatalf("unexpected args order: %v", args)
+		}
+	}
+	sql, _, err := From("user").WhereAnd("id", 1).BuildMapUpdate(row)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "update `user` as `user` set `user`.`age` = ?,`user`.`city` = ?,`user`.`email` = ?,`user`.`name` = ? where `user`.`id` = ?"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	sql, _ = From("user").BuildInsertSet(row)
+	if sql != "insert into `user` set `age` = ?, `city` = ?, `email` = ?, `name` = ?" {
+		t.Errorf("unexpected INSERT SET: %s", sql)
+	}
+}
+
+func TestColumnOrder_CallerSpecified(t *testing.T) {
+	rows := []map[string]any{
+		{"name": "a", "age": 1, "email": "x"},
+		{"name": "b", "age": 2, "email": "y"},
+	}
+	sql, args := From("user").ColumnOrder("name", "email").BuildSliceMapInsert(rows)
+	expected := "insert into `user` (`name`,`email`,`age`) values (?,?,?),(?,?,?)"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	if args[0] != "a" || args[1] != "x" || args[2] != 1 {
+		t.Errorf("unexpected args order: %v", args)
+	}
+
+	b := From("user").ColumnOrder("bad;col")
+	if b.err == nil {
+		t.Error("expected error for illegal column in ColumnOrder")
+	}
+}
+
+// ========== Heterogeneous Rows Tests ==========
+
+func TestSliceMapInsert_ValidatesEveryRowKey(t *testing.T) {
+	b := From("user")
+	sql, _ := b.BuildSliceMapInsert([]map[string]any{
+		{"name": "a"},
+		{"name; drop table user": "b"},
+	})
+	if sql != "" || b.err == nil {
+		t.Errorf("expected error for illegal key in later row, got sql=%q err=%v", sql, b.err)
+	}
+}
+
+func TestSliceMapInsert_ExactRowKeys(t *testing.T) {
+	rows := []map[string]any{
+		{"name": "a", "age": 1},
+		{"name": "b"},
+	}
+	b := From("user").ExactRowKeys()
+	sql, _ := b.BuildSliceMapInsert(rows)
+	if sql != "" || b.err == nil || !strings.Contains(b.err.Error(), "第 2 行缺少列 age") {
+		t.Errorf("expected mismatch error, got sql=%q err=%v", sql, b.err)
+	}
+
+	b = From("user").ExactRowKeys()
+	sql, _ = b.BuildSliceMapInsert([]map[string]any{{"name": "a"}, {"name": "b", "age": 2}})
+	if sql != "" || b.err == nil || !strings.Contains(b.err.Error(), "多出列 age") {
+		t.Errorf("expected extra-key error, got sql=%q err=%v", sql, b.err)
+	}
+
+	// 默认模式保持兼容，严格模式下报错
+	b = From("user")
+	if sql, _ = b.BuildSliceMapInsert(rows); sql == "" || b.err != nil {
+		t.Errorf("default mode should keep first-row columns, err=%v", b.err)
+	}
+	b = From("user").Strict()
+	if sql, _ = b.BuildSliceMapInsert(rows); sql != "" || b.err == nil {
+		t.Errorf("strict mode should reject mismatched rows, got %q", sql)
+	}
+}
+
+func TestSliceMapInsert_UnionRowKeys(t *testing.T) {
+	rows := []map[string]any{
+		{"name": "a", "age": 1},
+		{"name": "b", "email": "b@x.com"},
+	}
+	b := From("user").UnionRowKeys(map[string]any{"age": 0, "email": nil})
+	sql, args := b.BuildSliceMapInsert(rows)
+	if b.err != nil {
+		t.Fatalf("unexpected error: %v", b.err)
+	}
+	expected := "insert into `user` (`age`,`email`,`name`) values (?,?,?),(?,?,?)"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	want := []any{1, nil, "a", 0, "b@x.com", "b"}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Errorf("args[%d]: expected %v, got %v", i, want[i], args[i])
+		}
+	}
+
+	_, named := From("user").UnionRowKeys(map[string]any{"age": 0, "email": nil}).BuildSliceMapNamedInsert(rows)
+	if named[1]["age"] != 0 {
+		t.Errorf("named rows should be filled with defaults: %v", named)
+	}
+	if _, ok := rows[1]["age"]; ok {
+		t.Error("caller's rows must not be modified")
+	}
+
+	b = From("user").UnionRowKeys(map[string]any{"age": 0})
+	if sql, _ = b.BuildSliceMapInsert(rows); sql != "" || b.err == nil {
+		t.Errorf("expected error for missing default, got %q", sql)
+	}
+}
+
+// ========== Batch Insert Tests ==========
+
+func TestBatch_SliceMapByRowsAndPlaceholders(t *testing.T) {
+	rows := make([]map[string]any, 10)
+	for i := range rows {
+		rows[i] = map[string]any{"id": i, "name": fmt.Sprintf("u%d", i), "age": i}
+	}
+	stmts, err := From("user").BuildSliceMapInsertBatch(rows, BatchLimit{MaxRows: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stmts) != 3 || len(stmts[0].Args) != 12 || len(stmts[2].Args) != 6 {
+		t.Fatalf("expected 4/4/2 rows, got %d statements", len(stmts))
+	}
+	if stmts[2].SQL != "insert into `user` (`age`,`id`,`name`) values (?,?,?),(?,?,?)" {
+		t.Errorf("unexpected SQL: %s", stmts[2].SQL)
+	}
+
+	stmts, err = From("user").BuildSliceMapInsertBatch(rows, BatchLimit{MaxPlaceholders: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stmts) != 4 {
+		t.Fatalf("expected 4 statements of at most 3 rows, got %d", len(stmts))
+	}
+	total := 0
+	for _, s := range stmts {
+		if len(s.Args) > 10 {
+			t.Errorf("statement exceeds placeholder limit: %d args", len(s.Args))
+		}
+		total += len(s.Args)
+	}
+	if total != 30 {
+		t.Errorf("expected 30 args in total, got %d", total)
+	}
+}
+
+func TestBatch_SliceMapByBytes(t *testing.T) {
+	rows := make([]map[string]any, 50)
+	for i := range rows {
+		rows[i] = map[string]any{"name": strings.Repeat("x", 100)}
+	}
+	limit := BatchLimit{MaxBytes: 1000}
+	stmts, err := From("user").Dialect(Postgres).BuildSliceMapInsertBatch(rows, limit)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	total := 0
+	for _, s := range stmts {
+		if statementBytes(s.SQL, s.Args) > limit.MaxBytes {
+			t.Errorf("statement exceeds byte limit: %d", statementBytes(s.SQL, s.Args))
+		}
+		if !strings.HasSuffix(s.SQL, fmt.Sprintf("($%d)", len(s.Args))) {
+			t.Errorf("placeholders should restart in every statement: %s", s.SQL)
+		}
+		total += len(s.Args)
+	}
+	if total != 50 || len(stmts) < 2 {
+		t.Errorf("expected 50 rows split into several statements, got %d rows in %d", total, len(stmts))
+	}
+
+	_, err = From("user").BuildSliceMapInsertBatch(rows, BatchLimit{MaxBytes: 50})
+	if err == nil {
+		t.Error("expected error when a single row exceeds the byte limit")
+	}
+}
+
+func TestBatch_SliceStruct(t *testing.T) {
+	type User struct {
+		Name string `db:"name"`
+		Age  int    `db:"age"`
+	}
+	users := make([]User, 5)
+	for i := range users {
+		users[i] = User{Name: fmt.Sprintf("u%d", i), Age: i + 1}
+	}
+	stmts, err := From("user").BuildSliceStructInsertBatch(&users, BatchLimit{MaxPlaceholders: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stmts) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(stmts))
+	}
+	if stmts[0].SQL != "insert into `user` (`name`,`age`) values (?,?),(?,?)" {
+		t.Errorf("unexpected SQL: %s", stmts[0].SQL)
+	}
+	if stmts[2].Args[0] != "u4" {
+		t.Errorf("unexpected args: %v", stmts[2].Args)
+	}
+}
+
+// ========== On Conflict Tests ==========
+
+func TestOnConflict_PostgresDoUpdate(t *testing.T) {
+	sql, args := From("user").Dialect(Postgres).
+		OnConflict("email").
+		DoUpdate(map[string]any{"name": Excluded("name"), "login_count": Literal("login_count + 1"), "source": "api"}).
+		BuildMapInsert(map[string]any{"email": "a@x.com", "name": "a"})
+	expected := `insert into "user" ("email","name") values ($1,$2) on conflict ("email") do update set "login_count" = login_count + 1, "name" = "excluded"."name", "source" = $3`
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	if len(args) != 3 || args[2] != "api" {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+func TestOnConflict_DoUpdateWhereAndConstraint(t *testing.T) {
+	type User struct {
+		Email   string `db:"email"`
+		Version int    `db:"version"`
+	}
+	sql, args, err := From("user").Dialect(Postgres).
+		OnConflictConstraint("user_email_key").
+		DoUpdateWhere(map[string]any{"version": Excluded("version")}, "version", "<", Excluded("version")).
+		BuildStructInsert(&User{Email: "a@x.com", Version: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `insert into "user" ("email","version") values($1,$2) on conflict on constraint "user_email_key" do update set "version" = "excluded"."version" where "user"."version" < "excluded"."version"`
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	if len(args) != 2 {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	_, _, err = From("user").Dialect(SQLite).OnConflictConstraint("user_email_key").DoNothing().
+		BuildStructInsert(&User{Email: "a@x.com"})
+	if err == nil {
+		t.Error("expected error: SQLite does not support ON CONSTRAINT")
+	}
+
+	// 按特性判断而不是方言名称，包装 PostgreSQL 的自定义方言同样可用
+	sql, _, err = From("user").Dialect(namedDialect{Postgres, "cockroach"}).OnConflictConstraint("user_email_key").DoNothing().
+		BuildStructInsert(&User{Email: "a@x.com"})
+	if err != nil || !strings.HasSuffix(sql, `on conflict on constraint "user_email_key" do nothing`) {
+		t.Errorf("unexpected custom dialect result: %s %v", sql, err)
+	}
+}
+
+// namedDialect 替换名称的方言包装
+type namedDialect struct {
+	Dialect
+	name string
+}
+
+func (d namedDialect) Name() string { return d.name }
+
+func TestOnConflict_SQLiteDoNothingBulk(t *testing.T) {
+	sql, args := From("tag").Dialect(SQLite).OnConflict().DoNothing().
+		BuildSliceMapInsert([]map[string]any{{"name": "a"}, {"name": "b"}})
+	expected := `insert into "tag" ("name") values (?),(?) on conflict do nothing`
+	if sql != expected || len(args) != 2 {
+		t.Errorf("expected:\n%s\ngot:\n%s %v", expected, sql, args)
+	}
+}
+
+func TestOnConflict_PostgresExpressionQualified(t *testing.T) {
+	sql, args := From("user").Dialect(Postgres).
+		OnConflict("email").
+		DoUpdate(map[string]any{"login_count": []any{"login_count", "+", 1}}).
+		BuildMapInsert(map[string]any{"email": "a@x.com", "login_count": 1})
+	expected := `insert into "user" ("email","login_count") values ($1,$2) on conflict ("email") do update set "login_count" = "user"."login_count" + $3`
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	if len(args) != 3 || args[2] != 1 {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+func TestOnConflict_ExpressionDialectErrors(t *testing.T) {
+	b := From("user").Dialect(Postgres).OnConflict("email").DoUpdate(map[string]any{"name": Values("name")})
+	if sql, _ := b.BuildMapInsert(map[string]any{"email": "a", "name": "x"}); sql != "" || b.err == nil {
+		t.Errorf("expected error: VALUES(col) is MySQL only, got %q", sql)
+	}
+	b = From("user").Dialect(Postgres).OnDuplicateKey(map[string]any{"cnt": []any{"cnt", "+", 1}})
+	if sql, _ := b.BuildMapInsert(map[string]any{"email": "a"}); sql != "" || b.err == nil {
+		t.Errorf("expected error: Postgres does not support ON DUPLICATE KEY expressions, got %q", sql)
+	}
+}
+
+func TestOnConflict_Errors(t *testing.T) {
+	b := From("user").OnConflict("email").DoNothing()
+	if sql, _ := b.BuildMapInsert(map[string]any{"email": "a"}); sql != "" || b.err == nil {
+		t.Errorf("expected error: MySQL does not support ON CONFLICT, got %q", sql)
+	}
+	b = From("user").Dialect(Postgres).OnConflict().DoUpdate(map[string]any{"name": "x"})
+	if sql, _ := b.BuildMapInsert(map[string]any{"email": "a"}); sql != "" || b.err == nil {
+		t.Errorf("expected error: DO UPDATE requires a conflict target, got %q", sql)
+	}
+	b = From("user")
+	b.OnConflict("bad;col")
+	if b.err == nil {
+		t.Error("expected error for illegal conflict column")
+	}
+}
+
+// ========== On Duplicate Key Expression Tests ==========
+
+func TestOnDuplicateKey_Expressions(t *testing.T) {
+	sql, args := From("stat").OnDuplicateKey(map[string]any{
+		"cnt":        []any{"cnt", "+", Values("cnt")},
+		"hits":       []any{"hits", "+", 1},
+		"name":       Values("name"),
+		"updated_at": Literal("now()"),
+	}).BuildMapInsert(map[string]any{"id": 1, "cnt": 5, "hits": 1, "name": "a"})
+	expected := "insert into `stat` (`cnt`,`hits`,`id`,`name`) values (?,?,?,?) on duplicate key update `cnt` = `cnt` + values(`cnt`), `hits` = `hits` + ?, `name` = values(`name`), `updated_at` = now()"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+	if len(args) != 5 || args[4] != 1 {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	b := From("stat").OnDuplicateKey(map[string]any{"cnt": []any{"cnt", "; drop", 1}})
+	if sql, _ := b.BuildMapInsert(map[string]any{"cnt": 1}); sql != "" || b.err == nil {
+		t.Errorf("expected error for illegal operator, got %q", sql)
+	}
+}
+
+func TestOnDuplicateKey_RowAlias(t *testing.T) {
+	sql, _ := From("stat").RowAlias("new").
+		OnDuplicateKey(map[string]any{"cnt": []any{"cnt", "+", SField("new", "cnt", "")}}).
+		BuildSliceMapInsert([]map[string]any{{"id": 1, "cnt": 2}, {"id": 2, "cnt": 3}})
+	expected := "insert into `stat` (`cnt`,`id`) values (?,?),(?,?) as `new` on duplicate key update `cnt` = `cnt` + `new`.`cnt`"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+}
+
+func TestOnDuplicateKey_UpdateAll(t *testing.T) {
+	type User struct {
+		Id    int    `db:"id"`
+		Email string `db:"email"`
+		Name  string `db:"name"`
+		Age   int    `db:"age"`
+	}
+	sql, _, err := From("user").OnDuplicateKeyUpdateAll("id", "email").
+		OnDuplicateKey(map[string]any{"age": []any{"age", "+", 1}}).
+		BuildStructInsert(&User{Id: 1, Email: "a@x.com", Name: "a", Age: 18})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "insert into `user` (`id`,`email`,`name`,`age`) values(?,?,?,?) on duplicate key update `age` = `age` + ?, `name` = values(`name`)"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+
+	sql, _ = From("user").RowAlias("new").OnDuplicateKeyUpdateAll("id").
+		BuildMapInsert(map[string]any{"id": 1, "name": "a"})
+	expected = "insert into `user` (`id`,`name`) values (?,?) as `new` on duplicate key update `name` = `new`.`name`"
+	if sql != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sql)
+	}
+
+	b := From("user").OnDuplicateKeyUpdateAll("id")
+	if sql, _ := b.BuildMapInsert(map[string]any{"id": 1}); sql != "" || b.err == nil {
+		t.Errorf("expected error when nothing is left to update, got %q", sql)
+	}
+}
+
+// ========== Returning Tests ==========
+
+func TestReturning_Postgres(t *testing.T) {
+	sql, _ := From("user").Dialect(Postgres).Returning("id").
+		BuildMapInsert(map[string]any{"name": "a"})
+	if sql != `insert into "user" ("name") values ($1) returning "id"` {
+		t.Errorf("unexpected INSERT: %s", sql)
+	}
+
+	sql, _ = From("user").Dialect(Postgres).OnConflict("email").DoNothing().Returning("id", "email").
+		BuildSliceMapInsert([]map[string]any{{"email": "a"}, {"email": "b"}})
+	if sql != `insert into "user" ("email") values ($1),($2) on conflict ("email") do nothing returning "id", "email"` {
+		t.Errorf("unexpected bulk INSERT: %s", sql)
+	}
+
+	sql, args, err := From("user").Dialect(Postgres).WhereAnd("id", 1).Returning("*").
+		BuildMapUpdate(map[string]any{"name": "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql != `update "user" as "user" set "name" = $1 where "user"."id" = $2 returning *` || len(args) != 2 {
+		t.Errorf("unexpected UPDATE: %s %v", sql, args)
+	}
+
+	sql, _, err = From("user").Dialect(SQLite).WhereAnd("id", 1).Returning("id").BuildDelete()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(sql, `where "user"."id" = ? returning "id"`) {
+		t.Errorf("unexpected DELETE: %s", sql)
+	}
+}
+
+func TestReturning_StructInsertAndUpdate(t *testing.T) {
+	type User struct {
+		Id   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	sql, _, err := From("user").Dialect(SQLite).Returning("id").BuildStructInsert(&User{Name: "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql != `insert into "user" ("name") values(?) returning "id"` {
+		t.Errorf("unexpected INSERT: %s", sql)
+	}
+	sql, _, err = From("user").Dialect(Postgres).WhereAnd("id", 1).Returning("name").BuildStructUpdate(&User{Name: "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(sql, `returning "name"`) {
+		t.Errorf("unexpected UPDATE: %s", sql)
+	}
+}
+
+func TestReturning_UnsupportedDialect(t *testing.T) {
+	b := From("user").Returning("id")
+	if sql, _ := b.BuildMapInsert(map[string]any{"name": "a"}); sql != "" || b.err == nil {
+		t.Errorf("expected error for MySQL, got %q", sql)
+	}
+	if _, _, err := From("user").Dialect(SQLServer).WhereAnd("id", 1).Returning("id").BuildDelete(); err == nil {
+		t.Error("expected error for SQL Server")
+	}
+
+	// MariaDB 支持 INSERT/DELETE RETURNING，不支持 UPDATE RETURNING
+	sql, _ := From("user").Dialect(MariaDB).Returning("id").BuildMapInsert(map[string]any{"name": "a"})
+	if sql != "insert into `user` (`name`) values (?) returning `id`" {
+		t.Errorf("unexpected MariaDB INSERT: %s", sql)
+	}
+	sql, args, err := From("user").Dialect(MariaDB).WhereAnd("id", 1).Returning("id").BuildDelete()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql != "delete from `user` where `user`.`id` = ? returning `id`" || fmt.Sprint(args) != "[1]" {
+		t.Errorf("unexpected MariaDB DELETE: %s %v", sql, args)
+	}
+	sql, _, _ = From("user").Dialect(MariaDB).WhereAnd("id", 1).BuildDelete()
+	if sql != "delete `user` from `user` as `user` where `user`.`id` = ?" {
+		t.Errorf("unexpected MariaDB DELETE without RETURNING: %s", sql)
+	}
+	if _, _, err := From("user").Dialect(MariaDB).WhereAnd("id", 1).Returning("id").BuildMapUpdate(map[string]any{"name": "a"}); err == nil {
+		t.Error("expected error for MariaDB UPDATE RETURNING")
+	}
+	b = From("user")
+	b.Returning("id; drop")
+	if b.err == nil {
+		t.Error("expected error for illegal RETURNING column")
+	}
+}
+
+// ========== Select Struct Tests ==========
+
+type UserInfo struct {
+	Base
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+	Note string
+}
+
+type Base struct {
+	Id int64 `db:"id"`
+}
+
+type DeptInfo struct {
+	Id   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+func TestSelectStruct(t *testing.T) {
+	sql, _, err := From("user").As("u").SelectStruct(&UserInfo{}).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "select `u`.`id` as `id`,`u`.`name` as `name`,`u`.`age` as `age` from `user` as `u`"
+	if sql != expected {
+		t.Errorf("expected %s, got %s", expected, sql)
+	}
+}
+
+func TestSelectStructAs_WithJoin(t *testing.T) {
+	sql, _, err := From("user").As("u").
+		SelectStruct(UserInfo{}).
+		SelectStructAs(&DeptInfo{}, "d").
+		LeftJoin("dept", "d", "dept_id", "id").
+		BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "select `u`.`id` as `id`,`u`.`name` as `name`,`u`.`age` as `age`,`d`.`id` as `d.id`,`d`.`name` as `d.name` from `user` as `u` left join `dept` as `d` on `u`.`dept_id` = `d`.`id`"
+	if sql != expected {
+		t.Errorf("expected %s, got %s", expected, sql)
+	}
+
+	sql, _, _ = From("user").Dialect(Postgres).SelectStructAs(&DeptInfo{}, "d").BuildSelect()
+	if !strings.HasPrefix(sql, `select "d"."id" as "d.id","d"."name" as "d.name"`) {
+		t.Errorf("unexpected postgres select: %s", sql)
+	}
+}
+
+func TestSelectStruct_NamedNestedStruct(t *testing.T) {
+	type Emp struct {
+		Id      int64          `db:"id"`
+		Created time.Time      `db:"created"`
+		Nick    sql.NullString `db:"nick"`
+		Dept    DeptInfo       `db:"d"`
+		Boss    *DeptInfo      `db:"b"`
+		Skip    DeptInfo       `db:"-"`
+	}
+	query, _, err := From("emp").As("e").SelectStruct(&Emp{}).
+		LeftJoin("dept", "d", "dept_id", "id").
+		BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 与 executor 扫描的映射一致：d.id 对应 Dept.Id，time.Time 和 sql.NullString 作为单列
+	expected := "select `e`.`id` as `id`,`e`.`created` as `created`,`e`.`nick` as `nick`,`d`.`id` as `d.id`,`d`.`name` as `d.name`,`b`.`id` as `b.id`,`b`.`name` as `b.name` from `emp` as `e` left join `dept` as `d` on `e`.`dept_id` = `d`.`id`"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+
+	query, _, _ = From("emp").SelectStructAs(&Emp{}, "e").BuildSelect()
+	if !strings.Contains(query, "`d`.`id` as `e.d.id`") {
+		t.Errorf("expected nested alias prefixed with table alias, got %s", query)
+	}
+
+	type Bad struct {
+		Dept DeptInfo `db:"d;x"`
+	}
+	if _, _, err := From("emp").SelectStruct(&Bad{}).BuildSelect(); err == nil {
+		t.Error("expected error for illegal nested tag")
+	}
+}
+
+func TestSelectStruct_Errors(t *testing.T) {
+	if _, _, err := From("user").SelectStruct("user").BuildSelect(); err == nil {
+		t.Error("expected error for non-struct argument")
+	}
+	if _, _, err := From("user").SelectStructAs(&DeptInfo{}, "d`").BuildSelect(); err == nil {
+		t.Error("expected error for illegal table alias")
+	}
+}
+
+func TestSField_AliasIsQuoted(t *testing.T) {
+	sql, _, _ := From("user").As("u").Select(SField("u", "id", "uid")).BuildSelect()
+	if sql != "select `u`.`id` as `uid` from `user` as `u`" {
+		t.Errorf("unexpected select: %s", sql)
+	}
+}
+
+// ========== Struct Value Types Tests ==========
+
+// upperName 指针接收者实现 driver.Valuer
+type upperName struct{ s string }
+
+func (u *upperName) Value() (driver.Value, error) { return strings.ToUpper(u.s), nil }
+
+type Profile struct {
+	Id       int64          `db:"id"`
+	Nickname *string        `db:"nickname"`
+	Score    sql.NullInt64  `db:"score"`
+	Email    sql.NullString `db:"email"`
+	Enabled  bool           `db:"enabled"`
+	Avatar   []byte         `db:"avatar"`
+	Display  upperName      `db:"display"`
+}
+
+func TestStructUpdate_PointerValuerBoolBytes(t *testing.T) {
+	nick := "neo"
+	p := &Profile{
+		Nickname: &nick,
+		Score:    sql.NullInt64{Int64: 9, Valid: true},
+		Enabled:  false,
+		Avatar:   []byte{1, 2},
+		Display:  upperName{"x"},
+	}
+	sql, args, err := From("profile").WhereAnd("id", 1).BuildStructUpdate(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// false 与其他零值一致被跳过
+	expected := "update `profile` as `profile` set `profile`.`nickname` = ?,`profile`.`score` = ?,`profile`.`avatar` = ?,`profile`.`display` = ? where `profile`.`id` = ?"
+	if sql != expected {
+		t.Errorf("expected %s, got %s", expected, sql)
+	}
+	if len(args) != 5 || args[0] != "neo" {
+		t.Fatalf("unexpected args: %v", args)
+	}
+	if v, _ := args[1].(driver.Valuer).Value(); v != int64(9) {
+		t.Errorf("expected valid NullInt64 to bind 9, got %v", v)
+	}
+	if v, _ := args[3].(driver.Valuer).Value(); v != "X" {
+		t.Errorf("expected pointer receiver Valuer to be used, got %v", v)
+	}
+
+	// UpdateZeroField 声明后写入 false
+	sql, args, err = From("profile").WhereAnd("id", 1).UpdateZeroField("enabled").BuildStructUpdate(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(sql, "`profile`.`enabled` = ?") || args[2] != false {
+		t.Errorf("expected enabled = false, got %s %v", sql, args)
+	}
+}
+
+func TestStructUpdateByPK_PartialKeepsBool(t *testing.T) {
+	type U struct {
+		ID     int64  `db:"id,pk"`
+		Name   string `db:"name"`
+		Active bool   `db:"active"`
+	}
+	query, args, err := From("u").BuildStructUpdateByPK(&U{ID: 1, Name: "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query != "update `u` as `u` set `u`.`name` = ? where `u`.`id` = ?" || fmt.Sprint(args) != "[x 1]" {
+		t.Errorf("partial update should not overwrite bool columns: %s %v", query, args)
+	}
+}
+
+func TestStructUpdate_WhitelistedNullWritesNull(t *testing.T) {
+	sql, args, err := From("profile").WhereAnd("id", 1).
+		UpdateEmptyField("nickname", "email").
+		BuildStructUpdate(&Profile{Enabled: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "update `profile` as `profile` set `profile`.`nickname` = ?,`profile`.`email` = ?,`profile`.`enabled` = ?,`profile`.`display` = ? where `profile`.`id` = ?"
+	if sql != expected {
+		t.Errorf("expected %s, got %s", expected, sql)
+	}
+	if args[0] != nil {
+		t.Errorf("expected nil pointer to bind NULL, got %v", args[0])
+	}
+	if args[1] != nil {
+		t.Errorf("expected invalid NullString to bind NULL, got %v", args[1])
+	}
+}
+
+func TestStructInsert_PointerValuerBoolBytes(t *testing.T) {
+	nick := "neo"
+	query, args, err := From("profile").BuildStructInsert(&Profile{Id: 1, Nickname: &nick, Enabled: true, Avatar: []byte("a")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "insert into `profile` (`id`,`nickname`,`enabled`,`avatar`,`display`) values(?,?,?,?,?)"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	if args[1] != "neo" || args[2] != true {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = From("profile").BuildSliceStructInsert(&[]Profile{
+		{Id: 1, Nickname: &nick, Score: sql.NullInt64{Int64: 1, Valid: true}},
+		{Id: 2, Nickname: &nick, Score: sql.NullInt64{Int64: 2, Valid: true}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = "insert into `profile` (`id`,`nickname`,`score`,`display`) values (?,?,?,?),(?,?,?,?)"
+	if query != expected || len(args) != 8 || args[5] != "neo" {
+		t.Errorf("unexpected slice insert: %s %v", query, args)
+	}
+}
+
+// ========== Map Update Values Tests ==========
+
+func TestMapUpdate_NullBoolAndDriverValues(t *testing.T) {
+	var nilTime *time.Time
+	name := "neo"
+	query, args, err := From("user").WhereAnd("id", 1).BuildMapUpdate(map[string]any{
+		"deleted_at": nil,
+		"enabled":    false,
+		"avatar":     []byte("a"),
+		"nickname":   &name,
+		"locked_at":  nilTime,
+		"email":      sql.NullString{String: "a@x.com", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "update `user` as `user` set `user`.`avatar` = ?,`user`.`deleted_at` = ?,`user`.`email` = ?,`user`.`enabled` = ?,`user`.`locked_at` = ?,`user`.`nickname` = ? where `user`.`id` = ?"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	if len(args) != 7 || args[1] != nil || args[3] != false || args[4] != nil || args[5] != "neo" {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+func TestMapUpdate_UnsupportedValueIsError(t *testing.T) {
+	// 非严格模式下不支持的类型同样报错，不再静默跳过并留下多余的逗号
+	_, _, err := From("user").WhereAnd("id", 1).
+		BuildMapUpdate(map[string]any{"a": 1, "tags": map[string]int{"a": 1}, "z": 2})
+	if err == nil {
+		t.Fatal("expected error for unsupported update value")
+	}
+	if _, _, err := From("user").WhereAnd("id", 1).BuildMapUpdate(map[string]any{}); err == nil {
+		t.Error("expected error for empty update")
+	}
+}
+
+func TestUpdateWithJoin_NullAndBool(t *testing.T) {
+	query, args, err := From("user").As("u").
+		Join("dept", "d", "dept_id", "id").
+		WhereAnd("d.id", 3).
+		BuildUpdateWithJoin(map[string]any{"dept_name": nil, "enabled": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(query, "set `u`.`dept_name` = ?,`u`.`enabled` = ? where") {
+		t.Errorf("unexpected sql: %s", query)
+	}
+	if len(args) != 3 || args[0] != nil || args[1] != true {
+		t.Errorf("unexpected args: %v", args)
+	}
+	if _, _, err := From("user").As("u").Join("dept", "d", "dept_id", "id").WhereAnd("id", 1).
+		BuildUpdateWithJoin(map[string]any{"x": struct{}{}}); err == nil {
+		t.Error("expected error for unsupported update value")
+	}
+}
+
+// ========== Tag Options Tests ==========
+
+type Article struct {
+	Id        int64     `db:"id,pk,autoincr"`
+	Title     string    `db:"title"`
+	Draft     bool      `db:"draft,omitempty"`
+	Views     int       `db:"views,readonly"`
+	CreatedAt time.Time `db:"created_at,insertonly"`
+	UpdatedBy string    `db:"updated_by,updateonly"`
+}
+
+func TestTagOptions_Insert(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := &Article{Title: "t", Views: 9, CreatedAt: created, UpdatedBy: "root"}
+	query, args, err := From("article").BuildStructInsert(a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "insert into `article` (`title`,`created_at`) values(?,?)"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	if len(args) != 2 || args[1] != created {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	// 自增列有值时照常写入，omitempty 的 bool 为 true 时写入
+	query, _, _ = From("article").BuildStructInsert(&Article{Id: 5, Title: "t", Draft: true})
+	if query != "insert into `article` (`id`,`title`,`draft`,`created_at`) values(?,?,?,?)" {
+		t.Errorf("unexpected sql: %s", query)
+	}
+
+	query, _ = From("article").BuildStructNamedInsert(a)
+	if query != "insert into `article` (title,created_at) values(:title,:created_at)" {
+		t.Errorf("unexpected named sql: %s", query)
+	}
+
+	query, args, _ = From("article").BuildSliceStructInsert(&[]Article{{Title: "a", Views: 1}, {Title: "b", Views: 2}})
+	if query != "insert into `article` (`title`,`created_at`) values (?,?),(?,?)" || len(args) != 4 {
+		t.Errorf("unexpected slice sql: %s %v", query, args)
+	}
+}
+
+func TestTagOptions_Update(t *testing.T) {
+	a := &Article{Id: 1, Title: "t", Views: 9, CreatedAt: time.Now(), UpdatedBy: "root"}
+	query, args, err := From("article").WhereAnd("id", 1).BuildStructUpdate(a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "update `article` as `article` set `article`.`id` = ?,`article`.`title` = ?,`article`.`updated_by` = ? where `article`.`id` = ?"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	if len(args) != 4 || args[2] != "root" {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	// 声明过的字段不受 omitempty 影响，readonly 始终跳过
+	query, _, _ = From("article").WhereAnd("id", 1).UpdateZeroField("draft", "views").BuildStructUpdate(&Article{Title: "t"})
+	if query != "update `article` as `article` set `article`.`title` = ?,`article`.`draft` = ? where `article`.`id` = ?" {
+		t.Errorf("unexpected sql: %s", query)
+	}
+}
+
+func TestTagOptions_SelectStructUsesColumnName(t *testing.T) {
+	query, _, _ := From("article").Select().SelectStruct(&Article{}).BuildSelect()
+	if !strings.HasPrefix(query, "select `article`.`id` as `id`,`article`.`title` as `title`,`article`.`draft` as `draft`") {
+		t.Errorf("unexpected sql: %s", query)
+	}
+}
+
+// ========== By Primary Key Tests ==========
+
+type OrderItem struct {
+	OrderId   int64  `db:"order_id,pk"`
+	ProductId int64  `db:"product_id,pk"`
+	Qty       int    `db:"qty"`
+	Remark    string `db:"remark"`
+}
+
+func TestBuildStructUpdateByPK(t *testing.T) {
+	query, args, err := From("article").BuildStructUpdateByPK(&Article{Id: 7, Title: "t", UpdatedBy: "root"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "update `article` as `article` set `article`.`title` = ?,`article`.`updated_by` = ? where `article`.`id` = ?"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	if len(args) != 3 || args[2] != int64(7) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	// 复合主键，与已有条件组合
+	b := From("order_item").WhereAnd("tenant_id", 1)
+	query, args, err = b.BuildStructUpdateByPK(&OrderItem{OrderId: 1, ProductId: 2, Qty: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = "update `order_item` as `order_item` set `order_item`.`qty` = ? where (`order_item`.`tenant_id` = ?) and `order_item`.`order_id` = ? and `order_item`.`product_id` = ?"
+	if query != expected || len(args) != 4 {
+		t.Errorf("expected %s, got %s %v", expected, query, args)
+	}
+	// 原 builder 不受影响
+	if whStr := b.ToString(); strings.Contains(whStr, "order_id") {
+		t.Errorf("BuildStructUpdateByPK should not modify the builder: %s", whStr)
+	}
+}
+
+func TestBuildStructDeleteByPK(t *testing.T) {
+	query, args, err := From("order_item").BuildStructDeleteByPK(&OrderItem{OrderId: 1, ProductId: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query != "delete `order_item` from `order_item` as `order_item` where `order_item`.`order_id` = ? and `order_item`.`product_id` = ?" || len(args) != 2 {
+		t.Errorf("unexpected sql: %s %v", query, args)
+	}
+}
+
+func TestByPK_WithPriorWhereOr(t *testing.T) {
+	b := From("order_item").WhereAnd("status", 1).WhereOr("status", 2)
+	query, args, err := b.BuildStructUpdateByPK(&OrderItem{OrderId: 1, ProductId: 2, Qty: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "update `order_item` as `order_item` set `order_item`.`qty` = ? where (`order_item`.`status` = ? or `order_item`.`status` = ?) and `order_item`.`order_id` = ? and `order_item`.`product_id` = ?"
+	if query != expected || len(args) != 5 || args[3] != int64(1) || args[4] != int64(2) {
+		t.Errorf("expected %s, got %s %v", expected, query, args)
+	}
+
+	query, args, err = b.BuildStructDeleteByPK(&OrderItem{OrderId: 1, ProductId: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = "delete `order_item` from `order_item` as `order_item` where (`order_item`.`status` = ? or `order_item`.`status` = ?) and `order_item`.`order_id` = ? and `order_item`.`product_id` = ?"
+	if query != expected || len(args) != 4 {
+		t.Errorf("expected %s, got %s %v", expected, query, args)
+	}
+}
+
+func TestByPK_Errors(t *testing.T) {
+	if _, _, err := From("order_item").BuildStructDeleteByPK(&OrderItem{OrderId: 1}); err == nil {
+		t.Error("expected error for zero primary key")
+	}
+	if _, _, err := From("article").BuildStructUpdateByPK(&Article{Title: "t"}); err == nil {
+		t.Error("expected error for zero primary key")
+	}
+	if _, _, err := From("person").BuildStructUpdateByPK(&Person{Id: 1}); err == nil {
+		t.Error("expected error for struct without pk field")
+	}
+	if _, _, err := From("article").BuildStructDeleteByPK(Article{Id: 1}); err == nil {
+		t.Error("expected error for non-pointer argument")
+	}
+}
+
+// ========== Optimistic Lock Tests ==========
+
+type Document struct {
+	Id      int64  `db:"id,pk"`
+	Title   string `db:"title"`
+	Version int    `db:"version,version"`
+}
+
+func TestVersioned_StructUpdate(t *testing.T) {
+	query, args, err := From("document").WhereAnd("id", 1).BuildStructUpdate(&Document{Title: "t", Version: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "update `document` as `document` set `document`.`title` = ?,`document`.`version` = `document`.`version`+1 where (`document`.`id` = ?) and `document`.`version` = ?"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	if len(args) != 3 || args[0] != "t" || args[2] != 3 {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	// 版本号为 0 时同样作为条件；按主键更新时同样生效
+	query, args, _ = From("document").BuildStructUpdateByPK(&Document{Id: 1, Title: "t"})
+	if query != "update `document` as `document` set `document`.`title` = ?,`document`.`version` = `document`.`version`+1 where (`document`.`id` = ?) and `document`.`version` = ?" || args[2] != 0 {
+		t.Errorf("unexpected sql: %s %v", query, args)
+	}
+
+	// Versioned 指定无 version 选项的字段
+	query, _, err = From("person").Versioned("age").WhereAnd("id", 1).BuildStructUpdate(&Person{Name: "a", Age: 2})
+	if err != nil || !strings.Contains(query, "`person`.`age` = `person`.`age`+1") || !strings.HasSuffix(query, "and `person`.`age` = ?") {
+		t.Errorf("unexpected sql: %s %v", query, err)
+	}
+	if _, _, err := From("person").Versioned("rev").WhereAnd("id", 1).BuildStructUpdate(&Person{Name: "a"}); err == nil {
+		t.Error("expected error for missing version field")
+	}
+}
+
+func TestVersioned_MapUpdate(t *testing.T) {
+	query, args, err := From("document").Versioned("version").
+		WhereAnd("id", 1).WhereOr("id", 2).
+		BuildMapUpdate(map[string]any{"title": "t", "version": 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "update `document` as `document` set `document`.`title` = ?,`document`.`version` = `document`.`version`+1 where (`document`.`id` = ? or `document`.`id` = ?) and `document`.`version` = ?"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	if len(args) != 4 || args[3] != 5 {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := From("document").Versioned("version").WhereAnd("id", 1).
+		BuildMapUpdate(map[string]any{"title": "t"}); err == nil {
+		t.Error("expected error when current version is missing")
+	}
+	if _, _, err := From("document").Versioned("ver`sion").WhereAnd("id", 1).BuildMapUpdate(map[string]any{"a": 1}); err == nil {
+		t.Error("expected error for illegal version field")
+	}
+}
+
+// ========== Soft Delete Scope Tests ==========
+
+func TestSoftDeleteScope_Select(t *testing.T) {
+	query, args, err := From("user").As("u").SoftDelete("deleted_at").
+		LeftJoin("dept", "d", "dept_id", "id").
+		WhereAnd("status", 1).WhereOr("vip", 1).
+		BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "select `u`.* from `user` as `u` left join `dept` as `d` on `u`.`dept_id` = `d`.`id` where (`u`.`status` = ? or `u`.`vip` = ?) and `u`.`deleted_at` is null"
+	if query != expected || len(args) != 2 {
+		t.Errorf("expected %s, got %s %v", expected, query, args)
+	}
+
+	query, _, _ = From("user").SoftDelete("deleted_at").BuildSelectCount()
+	if query != "select count(*) as `_count` from (select `user`.* from `user` as `user` where `user`.`deleted_at` is null) as `_count`" {
+		t.Errorf("unexpected count sql: %s", query)
+	}
+
+	query, _, _ = From("user").SoftDelete("deleted_at").WithTrashed().BuildSelect()
+	if query != "select `user`.* from `user` as `user`" {
+		t.Errorf("unexpected with trashed sql: %s", query)
+	}
+	query, _, _ = From("user").SoftDelete("deleted_at").OnlyTrashed().WhereAnd("id", 1).BuildSelect()
+	if query != "select `user`.* from `user` as `user` where (`user`.`id` = ?) and `user`.`deleted_at` is not null" {
+		t.Errorf("unexpected only trashed sql: %s", query)
+	}
+}
+
+func TestSoftDeleteScope_JoinOnlyFiltersMainTable(t *testing.T) {
+	// 软删除范围只作用于主表，联表的已删除行不会被过滤
+	query, _, err := From("order").As("o").SoftDelete("deleted_at").
+		Join("order_item", "i", "id", "order_id").
+		WhereAnd("status", 1).
+		BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "select `o`.* from `order` as `o` join `order_item` as `i` on `o`.`id` = `i`.`order_id` where (`o`.`status` = ?) and `o`.`deleted_at` is null"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+
+	// 联表需自行追加条件
+	query, _, err = From("order").As("o").SoftDelete("deleted_at").
+		Join("order_item", "i", "id", "order_id").
+		WhereAnd("deleted_at", "is null", nil, "i").
+		BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(query, "where (`i`.`deleted_at` is null") || !strings.HasSuffix(query, "and `o`.`deleted_at` is null") {
+		t.Errorf("unexpected sql: %s", query)
+	}
+}
+
+func TestSoftDeleteScope_UpdateAndDelete(t *testing.T) {
+	b := From("user").SoftDelete("deleted_at").WhereAnd("id", 1)
+	query, _, _ := b.BuildMapUpdate(map[string]any{"name": "a"})
+	if query != "update `user` as `user` set `user`.`name` = ? where (`user`.`id` = ?) and `user`.`deleted_at` is null" {
+		t.Errorf("unexpected update sql: %s", query)
+	}
+	query, args, err := b.BuildSoftDelete()
+	if err != nil || query != "update `user` as `user` set `user`.`deleted_at` = ? where (`user`.`id` = ?) and `user`.`deleted_at` is null" || len(args) != 2 {
+		t.Errorf("unexpected soft delete sql: %s %v %v", query, args, err)
+	}
+	query, args, err = b.BuildRestore()
+	if err != nil || query != "update `user` as `user` set `user`.`deleted_at` = ? where (`user`.`id` = ?) and `user`.`deleted_at` is not null" || args[0] != nil {
+		t.Errorf("unexpected restore sql: %s %v %v", query, args, err)
+	}
+	query, _, _ = b.BuildDelete()
+	if query != "delete `user` from `user` as `user` where (`user`.`id` = ?) and `user`.`deleted_at` is null" {
+		t.Errorf("unexpected delete sql: %s", query)
+	}
+	query, _, _ = b.BuildForceDelete()
+	if query != "delete `user` from `user` as `user` where `user`.`id` = ?" {
+		t.Errorf("unexpected force delete sql: %s", query)
+	}
+	// 构建恢复、物理删除不影响原 builder 的范围
+	if query, _, _ := b.BuildSelect(); !strings.HasSuffix(query, "`user`.`deleted_at` is null") {
+		t.Errorf("scope should be unchanged: %s", query)
+	}
+}
+
+func TestSoftDeleteScope_WithVersioned(t *testing.T) {
+	b := From("document").SoftDelete("deleted_at").Versioned("version").WhereAnd("id", 1)
+	query, args, err := b.BuildSoftDelete()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 不校验版本号，只将版本号加 1
+	expected := "update `document` as `document` set `document`.`deleted_at` = ?,`document`.`version` = `document`.`version`+? where (`document`.`id` = ?) and `document`.`deleted_at` is null"
+	if query != expected || len(args) != 3 {
+		t.Errorf("expected %s, got %s %v", expected, query, args)
+	}
+	query, args, err = b.BuildRestore()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = "update `document` as `document` set `document`.`deleted_at` = ?,`document`.`version` = `document`.`version`+? where (`document`.`id` = ?) and `document`.`deleted_at` is not null"
+	if query != expected || len(args) != 3 || args[0] != nil {
+		t.Errorf("expected %s, got %s %v", expected, query, args)
+	}
+	// 原 builder 仍开启乐观锁
+	if b.VersionField() != "version" {
+		t.Errorf("BuildSoftDelete should not modify the builder: %q", b.VersionField())
+	}
+	if _, _, err := b.BuildMapUpdate(map[string]any{"title": "t"}); err == nil {
+		t.Error("expected error when current version is missing")
+	}
+}
+
+func TestSoftDeleteScope_RawOrIsParenthesized(t *testing.T) {
+	// 原始片段中的 OR 不以 " or " 出现时，软删除条件同样作用于整个用户条件
+	query, _, err := From("user").SoftDelete("deleted_at").WhereAnd("a", 1).Raw("OR\tb = 2").BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(query, "where (`user`.`a` = ? OR\tb = 2) and `user`.`deleted_at` is null") {
+		t.Errorf("unexpected sql: %s", query)
+	}
+}
+
+func TestSoftDeleteScope_DoesNotSatisfyConditionCheck(t *testing.T) {
+	b := From("user").SoftDelete("deleted_at")
+	if _, _, err := b.BuildMapUpdate(map[string]any{"name": "a"}); err == nil {
+		t.Error("expected error for update without user condition")
+	}
+	if _, _, err := b.BuildSoftDelete(); err == nil {
+		t.Error("expected error for soft delete without user condition")
+	}
+	if _, _, err := From("user").WhereAnd("id", 1).BuildRestore(); err == nil {
+		t.Error("expected error when soft delete field is not set")
+	}
+	if _, _, err := From("user").SoftDelete("a`b").BuildSelect(); err == nil {
+		t.Error("expected error for illegal soft delete field")
+	}
+}
+
+// ========== Global Scope Tests ==========
+
+func TestRegisterScope_AppliedToSelectUpdateDelete(t *testing.T) {
+	RegisterScope("scoped_order", "tenant", func(b *Builder) {
+		b.WhereAnd("tenant_id", 7)
+	})
+	RegisterScope("scoped_order", "visible", func(b *Builder) {
+		b.WhereAnd("hidden", 0)
+	})
+	defer UnregisterScope("scoped_order", "tenant")
+	defer UnregisterScope("scoped_order", "visible")
+
+	b := From("scoped_order").WhereAnd("status", 1).WhereOr("vip", 1)
+	query, args, err := b.BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "select `scoped_order`.* from `scoped_order` as `scoped_order` where (`scoped_order`.`status` = ? or `scoped_order`.`vip` = ?) and (`scoped_order`.`tenant_id` = ? and `scoped_order`.`hidden` = ?)"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	if fmt.Sprint(args) != "[1 1 7 0]" {
+		t.Errorf("unexpected args: %v", args)
+	}
+	// 范围作用于副本，原 builder 不变
+	if whStr := b.ToString(); strings.Contains(whStr, "tenant_id") {
+		t.Errorf("scope should not modify the builder: %s", whStr)
+	}
+
+	query, args, _ = From("scoped_order").WhereAnd("id", 1).BuildMapUpdate(map[string]any{"status": 2})
+	if query != "update `scoped_order` as `scoped_order` set `scoped_order`.`status` = ? where (`scoped_order`.`id` = ?) and (`scoped_order`.`tenant_id` = ? and `scoped_order`.`hidden` = ?)" || fmt.Sprint(args) != "[2 1 7 0]" {
+		t.Errorf("unexpected update: %s %v", query, args)
+	}
+	query, args, _ = From("scoped_order").WhereAnd("id", 1).BuildDelete()
+	if !strings.HasSuffix(query, "where (`scoped_order`.`id` = ?) and (`scoped_order`.`tenant_id` = ? and `scoped_order`.`hidden` = ?)") || len(args) != 3 {
+		t.Errorf("unexpected delete: %s %v", query, args)
+	}
+
+	// 范围不能代替用户条件
+	if _, _, err := From("scoped_order").BuildDelete(); err == nil {
+		t.Error("expected error for delete without user condition")
+	}
+
+	query, args, _ = From("scoped_order").WithoutScope("visible").WhereAnd("id", 1).BuildSelect()
+	if !strings.HasSuffix(query, "where (`scoped_order`.`id` = ?) and (`scoped_order`.`tenant_id` = ?)") || len(args) != 2 {
+		t.Errorf("unexpected select without scope: %s %v", query, args)
+	}
+	query, _, _ = From("scoped_order").WithoutScope("visible", "tenant").BuildSelect()
+	if query != "select `scoped_order`.* from `scoped_order` as `scoped_order`" {
+		t.Errorf("unexpected select without scopes: %s", query)
+	}
+}
+
+func TestRegisterScope_SubqueryAndVersionOrder(t *testing.T) {
+	RegisterScope("scoped_item", "tenant", func(b *Builder) {
+		b.WhereAnd("tenant_id", 7)
+	})
+	defer UnregisterScope("scoped_item", "tenant")
+
+	query, args, err := From("user").WhereAnd("id", "in", From("scoped_item").Select("user_id")).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(query, "where (`scoped_item`.`tenant_id` = ?)") || len(args) != 1 {
+		t.Errorf("expected scope applied to subquery: %s %v", query, args)
+	}
+
+	// 参数顺序：用户条件、范围、版本号
+	query, args, _ = From("scoped_item").Versioned("version").WhereAnd("id", 1).
+		BuildMapUpdate(map[string]any{"name": "a", "version": 3})
+	if !strings.HasSuffix(query, "where (`scoped_item`.`id` = ?) and (`scoped_item`.`tenant_id` = ?) and `scoped_item`.`version` = ?") || fmt.Sprint(args) != "[a 1 7 3]" {
+		t.Errorf("unexpected update: %s %v", query, args)
+	}
+}
+
+func TestRegisterScope_RawOrIsParenthesized(t *testing.T) {
+	RegisterScope("scoped_raw", "tenant", func(b *Builder) {
+		b.WhereAnd("tenant_id", 7).Raw("OR\tshared = 1")
+	})
+	defer UnregisterScope("scoped_raw", "tenant")
+	query, _, err := From("scoped_raw").WhereAnd("id", 1).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(query, "where (`scoped_raw`.`id` = ?) and (`scoped_raw`.`tenant_id` = ? OR\tshared = 1)") {
+		t.Errorf("unexpected sql: %s", query)
+	}
+}
+
+func TestRegisterScope_ErrorAndReplace(t *testing.T) {
+	RegisterScope("scoped_bad", "bad", func(b *Builder) {
+		b.WhereAnd("a`b", 1)
+	})
+	defer UnregisterScope("scoped_bad", "bad")
+	if _, _, err := From("scoped_bad").BuildSelect(); err == nil {
+		t.Error("expected error from scope")
+	}
+	// 同名范围重复注册时替换
+	RegisterScope("scoped_bad", "bad", func(b *Builder) {
+		b.WhereAnd("ok", 1)
+	})
+	if query, _, err := From("scoped_bad").BuildSelect(); err != nil || !strings.HasSuffix(query, "where (`scoped_bad`.`ok` = ?)") {
+		t.Errorf("unexpected select: %s %v", query, err)
+	}
+}
+
+func TestRequireCondition_Where(t *testing.T) {
+	RequireCondition("tenant_id", "guard_order")
+	defer RemoveRequiredCondition("tenant_id", "guard_order")
+
+	if _, _, err := From("guard_order").WhereAnd("id", 1).BuildSelect(); err == nil {
+		t.Error("expected error for select without tenant condition")
+	}
+	query, args, err := From("guard_order").WhereAnd("tenant_id", 7).WhereAnd("id", 1).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(query, "where `guard_order`.`tenant_id` = ? and `guard_order`.`id` = ?") || fmt.Sprint(args) != "[7 1]" {
+		t.Errorf("unexpected select: %s %v", query, args)
+	}
+	// 括号内的 or 不影响外层的租户条件
+	if _, _, err := From("guard_order").WhereAnd("tenant_id", 7).WhereAnd([][]any{{"status", 1}, {"vip", "=", 1, "guard_order", "or"}}).BuildSelect(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cases := map[string]*sqlBuilder{
+		"or branch":    From("guard_order").WhereAnd("tenant_id", 7).WhereOr("vip", 1),
+		"or before":    From("guard_order").WhereAnd("status", 1).WhereOr("vip", 1).WhereAnd("tenant_id", 7),
+		"not equal":    From("guard_order").WhereAnd("tenant_id", "in", []any{7, 8}),
+		"null value":   From("guard_order").WhereAnd("tenant_id", (*int)(nil)),
+		"other table":  From("guard_order").WhereAnd("tenant_id", "=", 7, "other"),
+		"raw or":       From("guard_order").WhereAnd("tenant_id", 7).WhereRawOr("1 = 1"),
+		"missing join": From("user").Join("guard_order", "o", "id", "user_id").WhereAnd("tenant_id", 7),
+		"subquery":     From("user").WhereAnd("tenant_id", 7).WhereAnd("id", "in", From("guard_order").Select("user_id")),
+	}
+	for name, b := range cases {
+		if _, _, err := b.BuildSelect(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if _, _, err := From("guard_order").WhereAnd("id", 1).BuildMapUpdate(map[string]any{"status": 2}); err == nil {
+		t.Error("expected error for update without tenant condition")
+	}
+	if _, _, err := From("guard_order").WhereAnd("id", 1).BuildDelete(); err == nil {
+		t.Error("expected error for delete without tenant condition")
+	}
+	if _, _, err := From("guard_order").WhereAnd("tenant_id", 7).WhereAnd("id", 1).BuildDelete(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// 未受保护的表不受影响
+	if _, _, err := From("user").WhereAnd("id", 1).BuildSelect(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRequireCondition_JoinAndScope(t *testing.T) {
+	RequireCondition("tenant_id", "guard_order", "guard_item")
+	defer RemoveRequiredCondition("tenant_id", "guard_order", "guard_item")
+
+	// 联表的条件可写在 WHERE 中，或通过 ON 与已满足条件的表的租户列相等
+	if _, _, err := From("user").Join("guard_order", "o", "id", "user_id").WhereAnd("tenant_id", "=", 7, "o").BuildSelect(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	b := From("guard_order").As("o").WhereAnd("tenant_id", 7).
+		JoinOn("guard_item", "i", []string{"o", "id", "=", "i", "order_id"}, []string{"o", "tenant_id", "=", "i", "tenant_id"})
+	if _, _, err := b.BuildSelect(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	b = From("guard_order").As("o").WhereAnd("tenant_id", 7).
+		JoinOn("guard_item", "i", []string{"o", "id", "=", "i", "order_id"})
+	if _, _, err := b.BuildSelect(); err == nil || !strings.Contains(err.Error(), "i") {
+		t.Errorf("expected error for joined table, got %v", err)
+	}
+
+	// 注册的范围同样可以满足条件
+	RegisterScope("guard_order", "tenant", func(b *Builder) {
+		b.WhereAnd("tenant_id", 7)
+	})
+	defer UnregisterScope("guard_order", "tenant")
+	if _, _, err := From("guard_order").WhereAnd("id", 1).WhereOr("vip", 1).BuildSelect(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, _, err := From("guard_order").WithoutScope("tenant").WhereAnd("id", 1).BuildSelect(); err == nil {
+		t.Error("expected error without tenant scope")
+	}
+}
+
+func TestRequireCondition_Global(t *testing.T) {
+	RequireCondition("tenant_id")
+	defer RemoveRequiredCondition("tenant_id")
+
+	if _, _, err := From("user").WhereAnd("id", 1).BuildSelect(); err == nil {
+		t.Error("expected error for global required condition")
+	}
+	if _, _, err := From("user").WhereAnd("tenant_id", 7).BuildSelect(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	RemoveRequiredCondition("tenant_id")
+	if _, _, err := From("user").WhereAnd("id", 1).BuildSelect(); err != nil {
+		t.Errorf("unexpected error after remove: %v", err)
+	}
+}
+
+type recordHook struct {
+	before int
+	events []*BuildEvent
+}
+
+func (h *recordHook) BeforeBuild(e *BuildEvent) { h.before++ }
+
+func (h *recordHook) AfterBuild(e *BuildEvent) { h.events = append(h.events, e) }
+
+func TestHook_Builder(t *testing.T) {
+	h := &recordHook{}
+	b := From("user").Hook(h).WhereAnd("id", 1)
+	query, args, err := b.BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.before != 1 || len(h.events) != 1 {
+		t.Fatalf("expected one event, got before=%d after=%d", h.before, len(h.events))
+	}
+	e := h.events[0]
+	if e.Op != "BuildSelect" || e.Table != "user" || e.SQL != query || fmt.Sprint(e.Args) != fmt.Sprint(args) || e.Err != nil || e.Start.IsZero() {
+		t.Errorf("unexpected event: %+v", e)
+	}
+
+	// 内部调用的 Build* 不重复触发
+	h.events = nil
+	b.BuildSelectCount()
+	b.SoftDelete("deleted_at").BuildSoftDelete()
+	b.BuildForceDelete()
+	b.BuildSliceStructInsertBatch(&[]Person{{Name: "a"}, {Name: "b"}}, BatchLimit{MaxRows: 1})
+	var ops []string
+	for _, e := range h.events {
+		ops = append(ops, e.Op)
+	}
+	if strings.Join(ops, ",") != "BuildSelectCount,BuildSoftDelete,BuildForceDelete,BuildSliceStructInsertBatch" {
+		t.Errorf("unexpected ops: %v", ops)
+	}
+	if last := h.events[len(h.events)-1]; strings.Count(last.SQL, "insert into") != 2 || fmt.Sprint(last.Args) != "[a b]" {
+		t.Errorf("unexpected batch event: %s %v", last.SQL, last.Args)
+	}
+
+	// 构建错误同样通知
+	h.events = nil
+	From("user").Hook(h).BuildDelete()
+	From("user").Hook(h).BuildMapInsert(map[string]any{"a`b": 1})
+	if len(h.events) != 2 || h.events[0].Err == nil || h.events[1].Err == nil {
+		t.Errorf("expected error events, got %+v", h.events)
+	}
+}
+
+func TestHook_GlobalAndDebug(t *testing.T) {
+	h := &recordHook{}
+	AddHook(h)
+	defer ClearHooks()
+	From("user").WhereAnd("id", 1).BuildMapUpdate(map[string]any{"name": "a"})
+	From("user").BuildStructNamedInsert(&Person{Name: "a"})
+	if len(h.events) != 2 || h.events[0].Op != "BuildMapUpdate" || h.events[1].Op != "BuildStructNamedInsert" {
+		t.Errorf("unexpected events: %+v", h.events)
+	}
+	ClearHooks()
+	From("user").WhereAnd("id", 1).BuildSelect()
+	if len(h.events) != 2 {
+		t.Errorf("hook should be removed, got %d events", len(h.events))
+	}
+
+	var buf strings.Builder
+	SetDebugLogger(log.New(&buf, "", 0))
+	defer SetDebugLogger(log.New(os.Stdout, "", 0))
+	From("user").Debug().WhereAnd("id", 1).BuildDelete()
+	if out := buf.String(); !strings.Contains(out, "BuildDelete delete `user` from `user` as `user` where `user`.`id` = ? | args: [1]") {
+		t.Errorf("unexpected debug output: %s", out)
+	}
+}
+
+func TestInterpolate_Dialects(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 120000000, time.FixedZone("CST", 8*3600))
+	name := "o'k"
+	args := []any{"it's \\ \n", []byte{0xde, 0xad}, ts, nil, true, 3, 1.5, &name, sql.NullString{}, (*int)(nil)}
+	cases := []struct {
+		d        Dialect
+		query    string
+		expected string
+	}{
+		{MySQL, "? ? ? ? ? ? ? ? ? ?", `'it\'s \\ \n' X'dead' '2024-05-06 07:08:09.12' NULL 1 3 1.5 'o\'k' NULL NULL`},
+		{MariaDB, "? ? ? ? ? ? ? ? ? ?", `'it\'s \\ \n' X'dead' '2024-05-06 07:08:09.12' NULL 1 3 1.5 'o\'k' NULL NULL`},
+		{Postgres, "$1 $2 $3 $4 $5 $6 $7 $8 $9 $10", `'it''s \ ` + "\n" + `' '\xdead' '2024-05-06 07:08:09.12+08:00' NULL TRUE 3 1.5 'o''k' NULL NULL`},
+		{SQLite, "? ? ? ? ? ? ? ? ? ?", `'it''s \ ` + "\n" + `' X'dead' '2024-05-06 07:08:09.12' NULL 1 3 1.5 'o''k' NULL NULL`},
+		{SQLServer, "@p1 @p2 @p3 @p4 @p5 @p6 @p7 @p8 @p9 @p10", `N'it''s \ ` + "\n" + `' 0xdead '2024-05-06 07:08:09.12' NULL 1 3 1.5 N'o''k' NULL NULL`},
+	}
+	for _, c := range cases {
+		got, err := Interpolate(c.d, c.query, args)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.d.Name(), err)
+		}
+		if got != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.d.Name(), c.expected, got)
+		}
+	}
+
+	// 引号内的占位符保持原样
+	if got, _ := Interpolate(MySQL, "select '?', `a?` from t where id = ?", []any{1}); got != "select '?', `a?` from t where id = 1" {
+		t.Errorf("unexpected interpolation: %s", got)
+	}
+	if _, err := Interpolate(MySQL, "id = ? and a = ?", []any{1}); err == nil {
+		t.Error("expected error for missing args")
+	}
+	if _, err := Interpolate(Postgres, "id = $1", []any{1, 2}); err == nil {
+		t.Error("expected error for extra args")
+	}
+	if _, err := Interpolate(MySQL, "id = ?", []any{struct{}{}}); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
+
+func TestInterpolate_Builder(t *testing.T) {
+	b := From("user").WhereAnd("name", "o'k").WhereAnd("status", true)
+	query, err := b.BuildSelectInterpolated()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "select `user`.* from `user` as `user` where `user`.`name` = 'o\\'k' and `user`.`status` = 1"
+	if query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	if b.String() != expected || fmt.Sprint(b) != expected {
+		t.Errorf("unexpected String: %s", b.String())
+	}
+
+	pg := From("user").Dialect(Postgres).WhereAnd("id", 1)
+	query, err = pg.Interpolate(pg.BuildMapUpdate(map[string]any{"name": "a", "deleted_at": nil}))
+	if err != nil || query != `update "user" as "user" set "deleted_at" = NULL,"name" = 'a' where "user"."id" = 1` {
+		t.Errorf("unexpected update: %s %v", query, err)
+	}
+	query, err = b.Interpolate(From("user").BuildMapInsert(map[string]any{"data": []byte("x")}))
+	if err != nil || query != "insert into `user` (`data`) values (X'78')" {
+		t.Errorf("unexpected insert: %s %v", query, err)
+	}
+
+	if _, err := From("user").Interpolate(From("user").BuildDelete()); err == nil {
+		t.Error("expected build error to be returned")
+	}
+	if s := From("user").WhereAnd("a`b", 1).String(); !strings.HasPrefix(s, "/* sqlbuilder: ") {
+		t.Errorf("unexpected String for error: %s", s)
+	}
+}
+
+func TestWhere_NotEqualOperator(t *testing.T) {
+	query, args, err := From("user").Strict().WhereAnd("status", NEQUAL, 1).
+		WhereAnd("level", "!= any", From("grade").Select("level")).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "select `user`.* from `user` as `user` where `user`.`status` != ? and `user`.`level` != any (select `grade`.`level` from `grade` as `grade`)"
+	if query != expected || fmt.Sprint(args) != "[1]" {
+		t.Errorf("expected %s, got %s %v", expected, query, args)
+	}
+}
+
+func TestWhere_SubqueryErrorReturned(t *testing.T) {
+	bad := func() *sqlBuilder { return From("order").Select("id;") }
+	cases := map[string]*sqlBuilder{
+		"equal":      From("user").WhereAnd("id", bad()).WhereAnd("x", 1),
+		"in":         From("user").WhereAnd("id", "in", bad()).WhereAnd("x", 1),
+		"exists":     From("user").WhereAnd("", "exists", bad()).WhereAnd("x", 1),
+		"any":        From("user").WhereAnd("id", "> any", bad()).WhereAnd("x", 1),
+		"having":     From("user").Group("id").HavingWhereAnd("id", "in", bad()),
+		"strict":     From("user").Strict().WhereAnd("id", bad()),
+		"nested sub": From("user").WhereAnd("id", "in", From("order").Select("user_id").WhereAnd("id", bad())),
+	}
+	for name, b := range cases {
+		if query, args, err := b.BuildSelect(); err == nil {
+			t.Errorf("%s: expected error, got %s %v", name, query, args)
+		}
+	}
+	if query, args, err := From("user").WhereAnd("id", bad()).WhereAnd("x", 1).BuildDelete(); err == nil {
+		t.Errorf("expected delete error, got %s %v", query, args)
+	}
+}
+
+func TestWhere_InAnySliceKind(t *testing.T) {
+	sql, args, err := From("t").WhereAnd("id", "in", []int{1, 2}).WhereAnd("code", "not in", [2]string{"a", "b"}).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql != "select `t`.* from `t` as `t` where `t`.`id` in (?,?) and `t`.`code` not in (?,?)" {
+		t.Errorf("unexpected sql: %s", sql)
+	}
+	if fmt.Sprint(args) != "[1 2 a b]" {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	// 空列表无法渲染为合法的 IN，返回错误
+	for name, v := range map[string]any{"empty int64": []int64{}, "empty int": []int{}, "bytes": []byte("ab"), "scalar": 1} {
+		if sql, _, err := From("t").WhereAnd("id", "in", v).BuildSelect(); err == nil {
+			t.Errorf("%s: expected error, got %s", name, sql)
+		}
+	}
+}
+
+func TestWhere_PlaceholdersMatchArgs(t *testing.T) {
+	query, args, err := From("user").WhereAnd("a", nil).WhereAnd("c", 1).BuildSelect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(query, "?") != len(args) {
+		t.Errorf("placeholders do not match args: %s %v", query, args)
+	}
+	// 无法渲染的值返回错误，不输出没有参数的占位符
+	if query, _, err := From("user").WhereAnd("b", SField("", "", "")).WhereAnd("c", 1).BuildSelect(); err == nil {
+		t.Errorf("expected error for unrenderable value, got %s", query)
+	}
+}
@@ -0,0 +1,90 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"strings"
+)
+
+// fieldTag 解析后的结构体 db tag，如 `db:"id,pk,autoincr"`，第一段为列名，其余为选项
+type fieldTag struct {
+	name string
+	// omitempty 零值（包括 false、零时间、空切片）和 NULL 时跳过
+	omitempty bool
+	// readonly 只读，插入和更新都跳过（如由数据库维护的列）
+	readonly bool
+	// insertonly 仅插入时写入，更新时跳过（如 created_at）
+	insertOnly bool
+	// updateonly 仅更新时写入，插入时跳过
+	updateOnly bool
+	// pk 主键
+	pk bool
+	// autoincr 自增列，插入时值为零则跳过
+	autoIncr bool
+	// version 乐观锁版本号，更新时自增并作为条件，见 Versioned
+	version bool
+}
+
+// 结构体字段的写入场景
+const (
+	structInsert = iota
+	structUpdate
+	// structUpdateByPK 按主键更新，主键作为条件，不出现在 SET 中
+	structUpdateByPK
+)
+
+// parseFieldTag 解析 db tag，未知选项忽略
+func parseFieldTag(tag string) fieldTag {
+	parts := strings.Split(tag, ",")
+	ft := fieldTag{name: strings.TrimSpace(parts[0])}
+	for _, opt := range parts[1:] {
+		switch strings.TrimSpace(opt) {
+		case "omitempty":
+			ft.omitempty = true
+		case "readonly":
+			ft.readonly = true
+		case "insertonly":
+			ft.insertOnly = true
+		case "updateonly":
+			ft.updateOnly = true
+		case "pk":
+			ft.pk = true
+		case "autoincr":
+			ft.autoIncr = true
+		case "version":
+			ft.version = true
+		}
+	}
+	return ft
+}
+
+// ignored 列名为空或 - 的字段不参与映射
+func (t fieldTag) ignored() bool {
+	return t.name == "" || t.name == "-"
+}
+
+// skipStructField 按 tag 选项和零值规则判断结构体字段在 op 场景下是否跳过
+// UpdateZeroField/UpdateEmptyField 声明的字段不受 omitempty 影响，但仍受 readonly 等写入限制
+func (b *sqlBuilder) skipStructField(val reflect.Value, tag fieldTag, op int) bool {
+	switch {
+	case tag.readonly:
+		return true
+	case op == structInsert && tag.updateOnly, op != structInsert && tag.insertOnly:
+		return true
+	case op == structUpdateByPK && tag.pk:
+		return true
+	case op == structInsert && tag.autoIncr && isEmptyValue(val):
+		return true
+	}
+	if tag.omitempty && isEmptyValue(val) && !b.zeroFieldMap[tag.name] && !b.emptyFieldMap[tag.name] {
+		return true
+	}
+	return b.shouldSkipField(val, tag.name)
+}
+
+// isEmptyValue 判断字段值是否为零值或 NULL
+func isEmptyValue(val reflect.Value) bool {
+	if val.Kind() == reflect.Slice || val.Kind() == reflect.Map {
+		return val.Len() == 0
+	}
+	return val.IsZero() || isNullValue(val)
+}
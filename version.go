@@ -0,0 +1,51 @@
+package sqlbuilder
+
+import "fmt"
+
+// versionLock 构建 UPDATE 时的乐观锁状态
+type versionLock struct {
+	column  string
+	current any
+}
+
+// Versioned 开启乐观锁，field 为版本号列
+// BuildStructUpdate 与 BuildMapUpdate 会在 SET 中追加 field = field + 1，并在条件中追加 field = 当前版本号；
+// 结构体取该字段的值作为当前版本号，map 取 option[field]。结构体也可以用 `db:"version,version"` 标记版本号字段
+func (b *sqlBuilder) Versioned(field string) *sqlBuilder {
+	if !isSafeIdentifier(field) {
+		b.err = fmt.Errorf("非法的版本号字段: %s", field)
+		return b
+	}
+	b.versionField = field
+	return b
+}
+
+// VersionField 返回 Versioned 设置的版本号列，未设置时为空
+func (b *sqlBuilder) VersionField() string {
+	return b.versionField
+}
+
+// isVersionTag 判断字段是否为版本号字段
+func (b *sqlBuilder) isVersionTag(tag fieldTag) bool {
+	return tag.version || (b.versionField != "" && tag.name == b.versionField)
+}
+
+// versionSet 返回版本号自增的 SET 片段
+func (b *sqlBuilder) versionSet(tableName string, col func(table, field string) string) string {
+	return fmt.Sprintf("%s = %s+1", col(tableName, b.version.column), b.quoteCol(tableName, b.version.column))
+}
+
+// versionCond 返回当前版本号校验条件，参数需追加在条件参数之后
+func (b *sqlBuilder) versionCond() string {
+	return fmt.Sprintf("%s = ?", b.quoteCol(b.condTable(), b.version.column))
+}
+
+// bumpVersion 用于软删除、恢复这类不持有当前版本号的内部更新：关闭版本号校验，
+// 在 option 中追加版本号自增，使持有旧版本号的更新失败。b 必须是副本
+func (b *sqlBuilder) bumpVersion(option map[string]any) map[string]any {
+	if b.versionField != "" {
+		option[b.versionField] = []any{b.versionField, "+", 1}
+		b.versionField = ""
+	}
+	return option
+}
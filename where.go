@@ -1,6 +1,7 @@
 package sqlbuilder
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -23,8 +24,35 @@ type Condition struct {
 
 	// 关系
 	Relation string
+
+	// 方言，由 ParseWhere 在渲染时填充，供运算符引用标识符和构建子查询
+	Dialect Dialect
+
+	// 字段载体（如 JsonField），渲染时按方言引用
+	expr any
+
+	// 子查询构建错误，由运算符通过 subquery 写入，parseWhere 据此返回错误
+	buildErr *error
 }
 
+// subquery 构建作为条件值的子查询，失败时记录错误供 parseWhere 返回
+func (w Condition) subquery(sub *sqlBuilder) (string, []any, error) {
+	query, args, err := sub.subquery(w.Dialect)
+	if err != nil && w.buildErr != nil {
+		*w.buildErr = err
+	}
+	return query, args, err
+}
+
+// condError 无法忽略的条件错误（如子查询构建失败），非严格模式下同样返回
+type condError struct {
+	err error
+}
+
+func (e *condError) Error() string { return e.err.Error() }
+
+func (e *condError) Unwrap() error { return e.err }
+
 type GroupWhere struct {
 	Relation  string
 	Condition []Condition
@@ -45,7 +73,16 @@ type Where struct {
 
 // 实现where接口
 func (r *Where) ParseWhere() (string, []any) {
+	whStr, fieldValue, _ := r.parseWhere(defaultDialect)
+	return whStr, fieldValue
+}
+
+// parseWhere 按方言渲染条件，占位符统一为 ?
+// 无法渲染的条件不写入结果并通过 error 返回，调用方必须将其视为错误；
+// 子查询构建失败时返回 *condError，调用方必须返回该错误
+func (r *Where) parseWhere(d Dialect) (string, []any, error) {
 	var fieldValue []any
+	var skipped error
 	var whStr strings.Builder
 	for _, bigGroup := range r.assembleWhere {
 		for j, v := range bigGroup {
@@ -59,8 +96,17 @@ func (r *Where) ParseWhere() (string, []any) {
 				} else {
 					w.TableName = tableName
 				}
+				w.Dialect = d
+				var buildErr error
+				w.buildErr = &buildErr
 				operator, placeholder, result := r.parseOperator(w)
+				if buildErr != nil {
+					return "", nil, &condError{err: fmt.Errorf("字段 %s 的子查询构建失败: %w", w.Field, buildErr)}
+				}
 				if operator == "" {
+					if skipped == nil {
+						skipped = fmt.Errorf("条件无法渲染: 字段 %s 运算符 %q 值 %v", w.Field, w.Condition, w.Value)
+					}
 					continue
 				}
 				fieldValue = append(fieldValue, result...)
@@ -85,10 +131,14 @@ func (r *Where) ParseWhere() (string, []any) {
 				}
 				// normal field
 				if w.FieldType == 1 {
-					whStr.WriteString(joinStr + "`" + tableName + "`.`" + w.Field + "` " + operator + " " + placeholder)
+					whStr.WriteString(joinStr + d.Quote(tableName) + "." + d.Quote(w.Field) + " " + operator + " " + placeholder)
 				} else if w.FieldType == 2 {
 					// special
-					whStr.WriteString(joinStr + w.Field + " " + operator + " " + placeholder)
+					field := w.Field
+					if jf, ok := w.expr.(*jsonFieldCarrier); ok {
+						field = jf.render(d)
+					}
+					whStr.WriteString(joinStr + field + " " + operator + " " + placeholder)
 				} else if w.FieldType == 3 {
 					// bare operator (EXISTS, etc.) — no field prefix
 					whStr.WriteString(joinStr + operator + " " + placeholder)
@@ -105,10 +155,7 @@ func (r *Where) ParseWhere() (string, []any) {
 	if whStr.String() == "" {
 		fieldValue = nil
 	}
-	// 重置
-	r.groupWhere = nil
-	r.assembleWhere = nil
-	return whStr.String(), fieldValue
+	return whStr.String(), fieldValue, skipped
 }
 
 func (r *Where) SetGroupWhere(groupWhere []GroupWhere) {